@@ -3,27 +3,73 @@ package main
 import (
 	"errors"
 	"fmt"
-	"path/filepath"
 	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
 	"github.com/mprpic/csafx/pkg/csaf/cache"
+	"github.com/mprpic/csafx/pkg/csaf/config"
 	"github.com/mprpic/csafx/pkg/csaf/download"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+	"github.com/mprpic/csafx/pkg/csaf/validate"
 	"github.com/mprpic/csafx/pkg/csaf/view"
 	"github.com/spf13/cobra"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "csafx",
 	Short: "CSAF Explorer",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		var err error
+		appConfig, err = config.Lookup(configFile)
+		if err != nil {
+			log.Fatalf("Error loading configuration: %v", err)
+		}
+
+		credentials, err := httpclient.LookupCredentials(credentialsFile)
+		if err != nil {
+			log.Fatalf("Error loading credentials: %v", err)
+		}
+		httpConfig.Credentials = append(credentials, appConfig.Credentials()...)
+
+		client, err := httpclient.New(httpConfig)
+		if err != nil {
+			log.Fatalf("Error configuring HTTP client: %v", err)
+		}
+		httpclient.SetDefault(client)
+	},
 }
 
 var (
-	providerURL  string
-	directoryURL string
-	clearAll     bool
-	interactive  bool
+	providerURL           string
+	directoryURL          string
+	feedURL               string
+	domain                string
+	aggregatorURLs        []string
+	providerSelectors     []string
+	distributionSelectors []string
+	allDistributions      bool
+	assumeYes             bool
+	configFile            string
+	forceSync             bool
+	appConfig             = &config.Config{}
+	clearAll              bool
+	interactive           bool
+	testIDs               []string
+	listTests             bool
+	profiles              []string
+	spellChecker          string
+	concurrency           int
+	maxPerHost            int
+	keepRemoved           bool
+	httpConfig            = httpclient.DefaultConfig
+	credentialsFile       string
 )
 
 var viewCmd = &cobra.Command{
@@ -60,7 +106,53 @@ Examples:
 var downloadCmd = &cobra.Command{
 	Use:   "download",
 	Short: "Download CSAF data set or update an existing one",
-	Long: `Download CSAF data set from provider metadata or directly from a directory URL.
+	Long: `Download CSAF data set from provider metadata or directly from a directory URL
+or ROLIE feed.
+
+Without a URL, providers are selected from the BSI aggregator, or from the
+aggregators given with --aggregator or listed under aggregators: in the
+configuration file ($CSAFX_CONFIG or ` + config.DefaultPath() + `). Providers listed
+by several aggregators are shown once; if a provider cannot be reached, the
+mirrors listed by the aggregators are used instead.
+
+Providers and distributions are chosen from a prompt, or with --select-provider,
+--select-distribution and --all-distributions. When stdin is not a terminal, the
+command fails instead of prompting.
+
+With --domain, the provider-metadata.json is discovered in the order defined by
+CSAF 2.0: https://<domain>/.well-known/csaf/provider-metadata.json, the CSAF fields
+of https://<domain>/.well-known/security.txt and https://csaf.data.security.<domain>.
+
+Distributions that only publish ROLIE feeds are downloaded from the feeds: every
+feed becomes a data set of its own, documents are fetched from the links of the feed
+entries and later syncs download the entries updated since the previous sync.
+
+When downloading from provider metadata, the provider's public OpenPGP keys are
+checked against the fingerprints in the metadata and used to verify the detached
+signature (.asc) of every document. Documents that fail verification are listed
+under signature_failures in the metadata.json file of the data set.
+
+Distributions of TLP:AMBER and TLP:RED documents usually require authentication.
+Credentials are read from the file given by --credentials, $CSAFX_CREDENTIALS or
+the default credentials file, and are sent to all URLs that start with their url:
+
+  providers:
+    - url: https://example.com/.well-known/csaf/
+      username: alice
+      password: ${EXAMPLE_PASSWORD}
+    - url: https://other.example/csaf/
+      token: ${OTHER_TOKEN}
+      headers:
+        X-Api-Key: ${OTHER_API_KEY}
+      client_cert: /path/to/cert.pem
+      client_key: /path/to/key.pem
+
+A single provider can also be configured with the CSAFX_AUTH_URL, CSAFX_AUTH_USERNAME,
+CSAFX_AUTH_PASSWORD and CSAFX_AUTH_TOKEN environment variables. The TLP label of
+every document is recorded in the metadata.json file of the data set.
+
+Archives are checked against their published hash. An interrupted archive download
+is resumed with HTTP range requests, also by the next run of the command.
 
 Examples:
   # Download from a specific provider metadata URL
@@ -69,18 +161,48 @@ Examples:
   # Download directly from a directory URL (where index.txt is located)
   csafx download --directory https://example.com/csaf/advisories/
 
+  # Discover the provider metadata of a domain and download from it
+  csafx download --domain example.com
+
+  # Select the provider and distributions without prompting, such as in cron jobs
+  csafx download --select-provider "/^Red Hat/" --select-distribution TLP:WHITE
+
+  # Download the documents listed in a ROLIE feed
+  csafx download --feed https://example.com/csaf/feed-tlp-white.json
+
   # Use BSI aggregator to select from available CSAF providers
-  csafx download`,
+  csafx download
+
+  # Select from the providers listed by several aggregators
+  csafx download --aggregator https://a.example/aggregator.json --aggregator https://b.example/aggregator.json`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if directoryURL != "" {
 			// Direct directory URL specified
-			err := downloadFromDirectoryURL(directoryURL)
+			err := downloadFromDirectoryURL(directoryURL, downloadOptions())
 			if err != nil {
 				log.Fatalf("Error downloading from directory: %v", err)
 			}
 			return
 		}
 
+		if feedURL != "" {
+			// ROLIE feed URL specified
+			err := downloadFromSource(download.Source{URL: feedURL, Feed: true}, downloadOptions())
+			if err != nil {
+				log.Fatalf("Error downloading from feed: %v", err)
+			}
+			return
+		}
+
+		if domain != "" {
+			// Provider metadata discovered from a domain name
+			err := downloadFromDomain(domain)
+			if err != nil {
+				log.Fatalf("Error downloading from domain: %v", err)
+			}
+			return
+		}
+
 		if providerURL != "" {
 			// Provider metadata URL specified
 			err := downloadFromProviderURL(providerURL)
@@ -90,7 +212,7 @@ Examples:
 			return
 		}
 
-		// No URL specified, use BSI aggregator
+		// No URL specified, use the configured aggregators
 		err := downloadFromAggregator()
 		if err != nil {
 			log.Fatalf("Error downloading from aggregator: %v", err)
@@ -98,10 +220,62 @@ Examples:
 	},
 }
 
+var validateCmd = &cobra.Command{
+	Use:   "validate <file|url|data-set>",
+	Short: "Validate CSAF documents against the CSAF 2.0 specification",
+	Long: `Validate CSAF documents against the official CSAF 2.0 JSON schema and the
+tests defined in section 6 of the CSAF specification.
+
+The --profile flag selects the groups of tests to run: mandatory (section 6.1,
+the default), optional (section 6.2), informative (section 6.3) or all. Schema
+violations and failed mandatory tests are reported as errors, failed optional tests
+as warnings and failed informative tests as info. Only errors fail the validation.
+
+The schemas are embedded in csafx, so validation works offline. The command accepts
+a local file, a URL to a remote CSAF file, a directory, or the name of a cached data set.
+Each violation is reported with a JSON pointer to the offending part of the document.
+
+Examples:
+  # Validate a local file
+  csafx validate /path/to/csaf-document.json
+
+  # Validate a remote file
+  csafx validate https://example.com/advisories/document.json
+
+  # Validate all documents in a cached data set
+  csafx validate example.com_csaf_advisories
+
+  # Run only selected tests (a section such as 6.1.27 selects all its tests)
+  csafx validate --test 6.1.1,6.1.27 /path/to/csaf-document.json
+
+  # Also run the optional and informative tests
+  csafx validate --profile all /path/to/csaf-document.json
+
+  # Spell check the document text with hunspell (test 6.3.8)
+  csafx validate --test 6.3.8 --spell-checker "hunspell -l -d {lang}" /path/to/csaf-document.json
+
+  # List all available tests
+  csafx validate --list-tests`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if listTests {
+			printTests()
+			return
+		}
+		if len(args) != 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+		if err := validateDocuments(args[0]); err != nil {
+			log.Fatalf("Error validating CSAF documents: %v", err)
+		}
+	},
+}
+
 var cacheCmd = &cobra.Command{
 	Use:   "cache",
 	Short: "Manage CSAF cache",
-	Long:  "Manage the local CSAF cache with list and clear operations",
+	Long:  "Manage the local CSAF cache with list, clear, sync and verify operations",
 }
 
 var cacheListCmd = &cobra.Command{
@@ -158,9 +332,105 @@ Examples:
 	},
 }
 
+var syncCmd = &cobra.Command{
+	Use:   "sync [source...]",
+	Short: "Bring the sources of the configuration file up to date",
+	Long: `Download or update the data sets of the providers, directories and ROLIE feeds
+listed under sources: in the configuration file. Sources are synced by their name
+or alias, or all of them if none is given. Data sets that do not exist yet are
+created by a full download.
+
+The configuration file is read from --config, $CSAFX_CONFIG or
+` + config.DefaultPath() + `:
+
+  sources:
+    - name: example
+      aliases: [ex]
+      provider: https://example.com/.well-known/csaf/provider-metadata.json
+      tlp: [WHITE, GREEN]
+      concurrency: 4
+      max_per_host: 2
+      sync_ttl: 12h
+      credentials:
+        token: ${EXAMPLE_TOKEN}
+    - name: other
+      domain: other.example
+    - name: advisories
+      directory: https://example.org/csaf/white/
+    - name: feed
+      feed: https://example.net/csaf/feed-tlp-white.json
+
+A source sets exactly one of provider, domain (discovered like download --domain),
+directory and feed. tlp limits the distributions of a provider to those labels;
+distributions whose label is not known are always synced. Data sets that were
+synced completely within sync_ttl are skipped unless --force is given. The
+credentials accept the fields of the credentials file; their url defaults to the
+scheme and host of the source, but must be set for domain sources because the
+provider metadata may be found on another host. --concurrency and --max-per-host override the values
+of the sources when given.
+
+Examples:
+  # Sync all configured sources
+  csafx sync
+
+  # Sync selected sources by name or alias
+  csafx sync example ex`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := syncConfiguredSources(cmd, args); err != nil {
+			log.Fatalf("Error syncing sources: %v", err)
+		}
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify <data-set>",
+	Short: "Verify cached documents against their stored hashes",
+	Long: `Re-check every document of a cached data set against the .sha512 or .sha256
+file stored next to it. The check works offline and does not download anything.
+
+Examples:
+  # Verify a cached data set
+  csafx cache verify example.com_csaf_advisories`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := verifyDataSet(args[0]); err != nil {
+			log.Fatalf("Error verifying cache: %v", err)
+		}
+	},
+}
+
 func init() {
+	rootCmd.PersistentFlags().DurationVar(&httpConfig.Timeout, "timeout", httpConfig.Timeout, "Timeout for connecting to a server, receiving the response headers and waiting for more of a response body (0 for no timeout)")
+	rootCmd.PersistentFlags().StringVar(&httpConfig.Proxy, "proxy", "", "Proxy URL used for all requests (default: HTTP_PROXY, HTTPS_PROXY and NO_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&httpConfig.CACertFile, "ca-cert", "", "PEM bundle of additional trusted certificate authorities")
+	rootCmd.PersistentFlags().StringVar(&httpConfig.ClientCertFile, "client-cert", "", "PEM client certificate for servers that require mutual TLS")
+	rootCmd.PersistentFlags().StringVar(&httpConfig.ClientKeyFile, "client-key", "", "PEM private key of the client certificate")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "YAML configuration file (default: $CSAFX_CONFIG or "+config.DefaultPath()+")")
+	rootCmd.PersistentFlags().StringVar(&credentialsFile, "credentials", "", "YAML file with per-provider credentials (default: $CSAFX_CREDENTIALS or "+httpclient.DefaultCredentialsPath()+")")
+	rootCmd.PersistentFlags().IntVar(&httpConfig.Retry.MaxRetries, "retries", httpConfig.Retry.MaxRetries, "Number of retries of failed HTTP requests (0 disables retries)")
+	rootCmd.PersistentFlags().DurationVar(&httpConfig.Retry.InitialBackoff, "retry-backoff", httpConfig.Retry.InitialBackoff, "Wait before the first retry, doubled for every further retry")
+	rootCmd.PersistentFlags().DurationVar(&httpConfig.Retry.MaxBackoff, "retry-max-backoff", httpConfig.Retry.MaxBackoff, "Maximum wait between retries")
+	rootCmd.PersistentFlags().DurationVar(&httpConfig.Retry.MaxRetryAfter, "max-retry-after", httpConfig.Retry.MaxRetryAfter, "Longest Retry-After requested by a server that is waited for")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to all confirmation prompts")
+
 	downloadCmd.Flags().StringVarP(&providerURL, "provider", "p", "", "URL to a provider-metadata.json file that points to a CSAF data set")
 	downloadCmd.Flags().StringVarP(&directoryURL, "directory", "d", "", "URL to a CSAF directory that contains an index.txt file")
+	downloadCmd.Flags().StringVar(&feedURL, "feed", "", "URL to a ROLIE feed that lists CSAF documents")
+	downloadCmd.Flags().StringSliceVar(&aggregatorURLs, "aggregator", nil, "aggregator.json URL providers are selected from, repeatable (default: aggregators of the configuration file or the BSI aggregator)")
+	downloadCmd.Flags().StringSliceVar(&providerSelectors, "select-provider", nil, "Select aggregator providers by position, name, namespace or /regex/ instead of prompting, repeatable")
+	downloadCmd.Flags().StringSliceVar(&distributionSelectors, "select-distribution", nil, "Select distributions by position, URL, TLP label or /regex/ instead of prompting, repeatable")
+	downloadCmd.Flags().BoolVar(&allDistributions, "all-distributions", false, "Download all distributions of the provider instead of prompting")
+	downloadCmd.Flags().StringVar(&domain, "domain", "", "Domain name whose provider-metadata.json is discovered as defined by CSAF 2.0")
+	for _, cmd := range []*cobra.Command{downloadCmd, cacheSyncCmd, syncCmd} {
+		cmd.Flags().IntVarP(&concurrency, "concurrency", "c", download.DefaultConcurrency, "Number of files downloaded in parallel")
+		cmd.Flags().IntVar(&maxPerHost, "max-per-host", 0, "Maximum number of parallel requests to a single host (0 for no limit)")
+		cmd.Flags().BoolVar(&keepRemoved, "keep-removed", false, "Keep documents that are no longer listed in index.txt instead of removing them")
+	}
+
+	validateCmd.Flags().StringSliceVarP(&testIDs, "test", "t", nil, "Run only the tests with the given IDs (overrides --profile)")
+	validateCmd.Flags().StringSliceVarP(&profiles, "profile", "p", []string{validate.ProfileMandatory}, "Test groups to run: mandatory, optional, informative or all")
+	validateCmd.Flags().StringVar(&spellChecker, "spell-checker", "", "Command used by test 6.3.8 that reads text on stdin and prints misspelled words, {lang} is replaced with the document language")
+	validateCmd.Flags().BoolVar(&listTests, "list-tests", false, "List all available tests")
 
 	cacheClearCmd.Flags().BoolVar(&clearAll, "all", false, "Clear all cached CSAF data sets")
 	cacheClearCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive multi-select of data sets to clear")
@@ -168,13 +438,18 @@ func init() {
 	cacheSyncCmd.Flags().BoolVar(&clearAll, "all", false, "Sync all cached CSAF data sets")
 	cacheSyncCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive multi-select of data sets to sync")
 
+	syncCmd.Flags().BoolVar(&forceSync, "force", false, "Sync data sets even if they were synced within the sync_ttl of their source")
+
 	cacheCmd.AddCommand(cacheListCmd)
 	cacheCmd.AddCommand(cacheClearCmd)
 	cacheCmd.AddCommand(cacheSyncCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
 
 	rootCmd.AddCommand(downloadCmd)
 	rootCmd.AddCommand(viewCmd)
+	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(syncCmd)
 }
 
 func main() {
@@ -184,11 +459,34 @@ func main() {
 	}
 }
 
+// downloadOptions returns the download options set by command line flags
+func downloadOptions() download.Options {
+	return download.Options{
+		Concurrency: concurrency,
+		MaxPerHost:  maxPerHost,
+		KeepRemoved: keepRemoved,
+	}
+}
+
 // downloadFromDirectoryURL handles CLI interaction for directory URL downloads
-func downloadFromDirectoryURL(directoryURL string) error {
+func downloadFromDirectoryURL(directoryURL string, opts download.Options) error {
 	fmt.Printf("Downloading from directory: %s\n", directoryURL)
 	fmt.Println("Checking for available archive...")
-	targetPath, err := download.FromDirectoryURL(directoryURL)
+	targetPath, err := download.FromDirectoryURL(directoryURL, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully downloaded to: %s\n", targetPath)
+	return nil
+}
+
+// downloadFromSource handles CLI interaction for downloads from a directory or a ROLIE feed
+func downloadFromSource(source download.Source, opts download.Options) error {
+	if !source.Feed {
+		return downloadFromDirectoryURL(source.URL, opts)
+	}
+	fmt.Printf("Downloading from ROLIE feed: %s\n", source.URL)
+	targetPath, err := download.FromRolieFeed(source.URL, opts)
 	if err != nil {
 		return err
 	}
@@ -203,36 +501,76 @@ func downloadFromProviderURL(providerURL string) error {
 	if err != nil {
 		return err
 	}
+	return downloadFromProviderMetadata(providerURL, providerMetadata)
+}
 
+// downloadFromProviderMetadata handles CLI interaction for downloads from the distributions
+// of fetched provider metadata
+func downloadFromProviderMetadata(providerURL string, providerMetadata *download.ProviderMetadata) error {
 	fmt.Printf("Provider: %s (%s)\n", providerMetadata.Publisher.Name, providerMetadata.Publisher.Category)
 
 	urlSet := make(map[string]struct{})
+	var sources []download.Source
 	for _, dist := range providerMetadata.Distributions {
-		dirURLs, err := dist.GetDirectoryURLs()
+		distSources, err := dist.GetSources()
 		if err != nil {
-			fmt.Printf("Warning: failed to get directory URLs for distribution: %v\n", err)
+			fmt.Printf("Warning: failed to get sources for distribution: %v\n", err)
 			continue
 		}
-		for _, dirURL := range dirURLs {
-			urlSet[dirURL] = struct{}{}
+		for _, source := range distSources {
+			if _, ok := urlSet[source.URL]; ok {
+				continue
+			}
+			urlSet[source.URL] = struct{}{}
+			sources = append(sources, source)
 		}
 	}
-
-	var allDirURLs []string
-	for url := range urlSet {
-		allDirURLs = append(allDirURLs, url)
+	if len(sources) == 0 {
+		return fmt.Errorf("no usable distributions found in provider metadata")
 	}
-	if len(allDirURLs) == 1 {
-		if err := downloadFromDirectoryURL(allDirURLs[0]); err != nil {
-			return fmt.Errorf("failed to download from %s: %w", allDirURLs[0], err)
+
+	opts := downloadOptions()
+	opts.ProviderURL = providerURL
+	if len(sources) == 1 {
+		if err := downloadFromSource(sources[0], opts); err != nil {
+			return fmt.Errorf("failed to download from %s: %w", sources[0].URL, err)
 		}
 		return nil
 	}
 
 	var items []string
-	for _, url := range allDirURLs {
-		items = append(items, url)
+	for _, source := range sources {
+		items = append(items, source.String())
+	}
+
+	switch {
+	case allDistributions:
+		return downloadSources(sources, opts)
+	case len(distributionSelectors) > 0:
+		indexes, err := selectItems(distributionSelectors, items, func(i int) []string {
+			if sources[i].TLPLabel == "" {
+				return []string{sources[i].URL}
+			}
+			return []string{sources[i].URL, sources[i].TLPLabel, "TLP:" + sources[i].TLPLabel}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to select distributions: %w", err)
+		}
+		var selected []download.Source
+		for _, i := range indexes {
+			selected = append(selected, sources[i])
+		}
+		if len(selected) == 1 {
+			return downloadFromSource(selected[0], opts)
+		}
+		return downloadSources(selected, opts)
+	}
+
+	if err := requireTerminal("use --select-distribution or --all-distributions to select distributions"); err != nil {
+		printChoices(items)
+		return err
 	}
+
 	items = append(items, "Download all")
 	prompt := promptui.Select{
 		Label:    "Select data set to download",
@@ -250,49 +588,137 @@ func downloadFromProviderURL(providerURL string) error {
 	}
 
 	if choice == "Download all" {
-		var targetPaths []string
-		var errors []error
+		return downloadSources(sources, opts)
+	}
 
-		for _, dirURL := range allDirURLs {
-			targetPath, err := download.FromDirectoryURL(dirURL)
-			if err != nil {
-				errors = append(errors, fmt.Errorf("failed to download %s: %w", dirURL, err))
-				continue
-			}
-			targetPaths = append(targetPaths, targetPath)
-		}
+	return downloadFromSource(sources[choiceIndex], opts)
+}
 
-		for _, path := range targetPaths {
-			fmt.Printf("Downloaded to: %s\n", path)
-		}
-		for _, err := range errors {
-			fmt.Printf("Failed to download: %v\n", err)
+// downloadSources downloads several data sets, continuing with the others if one fails
+func downloadSources(sources []download.Source, opts download.Options) error {
+	var targetPaths []string
+	var errors []error
+
+	for _, source := range sources {
+		targetPath, err := download.FromSource(source, opts)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed to download %s: %w", source.URL, err))
+			continue
 		}
-		if len(errors) > 0 {
-			return fmt.Errorf("completed with %d errors", len(errors))
+		targetPaths = append(targetPaths, targetPath)
+	}
+
+	for _, path := range targetPaths {
+		fmt.Printf("Downloaded to: %s\n", path)
+	}
+	for _, err := range errors {
+		fmt.Printf("Failed to download: %v\n", err)
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("completed with %d errors", len(errors))
+	}
+	return nil
+}
+
+// downloadFromDomain discovers the provider metadata of a domain and downloads from it
+func downloadFromDomain(domain string) error {
+	fmt.Printf("Discovering CSAF provider metadata of %s\n", domain)
+	discovery, err := download.DiscoverProvider(domain)
+	var discoveryErr *download.DiscoveryError
+	if errors.As(err, &discoveryErr) {
+		printDiscoveryAttempts(discoveryErr.Attempts)
+		return fmt.Errorf("no CSAF provider metadata found for %s", domain)
+	}
+	if err != nil {
+		return err
+	}
+
+	printDiscoveryAttempts(discovery.Attempts)
+	fmt.Printf("Found provider metadata via %s: %s\n", discovery.Method(), discovery.ProviderURL)
+	return downloadFromProviderMetadata(discovery.ProviderURL, discovery.Metadata)
+}
+
+// printDiscoveryAttempts lists the discovery methods that were tried and why they failed
+func printDiscoveryAttempts(attempts []download.DiscoveryAttempt) {
+	for _, attempt := range attempts {
+		if attempt.Err != nil {
+			fmt.Printf("  %s: failed: %v\n", attempt.Method, attempt.Err)
+		} else {
+			fmt.Printf("  %s: found %s\n", attempt.Method, attempt.URL)
 		}
-		return nil
 	}
+}
 
-	return downloadFromDirectoryURL(allDirURLs[choiceIndex])
+// configuredAggregators returns the aggregators given by --aggregator, those of the
+// configuration file or the BSI aggregator, in that order
+func configuredAggregators() []string {
+	if len(aggregatorURLs) > 0 {
+		return aggregatorURLs
+	}
+	if len(appConfig.Aggregators) > 0 {
+		return appConfig.Aggregators
+	}
+	return []string{download.DefaultAggregatorURL}
 }
 
 // downloadFromAggregator handles CLI interaction for aggregator-based downloads
 func downloadFromAggregator() error {
-	fmt.Println("Fetching CSAF provider list from BSI aggregator...")
+	aggregators := configuredAggregators()
+	switch {
+	case len(aggregators) == 1 && aggregators[0] == download.DefaultAggregatorURL:
+		fmt.Println("Fetching CSAF provider list from BSI aggregator...")
+	case len(aggregators) == 1:
+		fmt.Printf("Fetching CSAF provider list from %s...\n", aggregators[0])
+	default:
+		fmt.Printf("Fetching CSAF provider list from %d aggregators...\n", len(aggregators))
+	}
 
-	aggregator, err := download.GetAvailableProviders()
+	providers, err := download.GetAvailableProviders(aggregators)
 	if err != nil {
 		return fmt.Errorf("failed to fetch aggregator data: %w", err)
 	}
 
-	if len(aggregator.CSAFProviders) == 0 {
+	if len(providers) == 0 {
 		return fmt.Errorf("no providers found in aggregator")
 	}
 
 	var items []string
-	for _, provider := range aggregator.CSAFProviders {
-		items = append(items, fmt.Sprintf("%s (%s)", provider.Metadata.Publisher.Name, provider.Metadata.Publisher.Namespace))
+	for _, provider := range providers {
+		item := fmt.Sprintf("%s (%s)", provider.Metadata.Publisher.Name, provider.Metadata.Publisher.Namespace)
+		if provider.Publisher {
+			item += " [publisher]"
+		}
+		items = append(items, item)
+	}
+
+	if len(providerSelectors) > 0 {
+		indexes, err := selectItems(providerSelectors, items, func(i int) []string {
+			metadata := providers[i].Metadata
+			return []string{metadata.Publisher.Name, metadata.Publisher.Namespace, metadata.URL}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to select providers: %w", err)
+		}
+		if len(indexes) == 1 {
+			return downloadFromAvailableProvider(providers[indexes[0]])
+		}
+
+		var failed int
+		for _, i := range indexes {
+			if err := downloadFromAvailableProvider(providers[i]); err != nil {
+				fmt.Printf("Failed to download from %s: %v\n", providers[i].Metadata.Publisher.Name, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("downloads from %d of %d providers failed", failed, len(indexes))
+		}
+		return nil
+	}
+
+	if err := requireTerminal("use --select-provider to select providers"); err != nil {
+		printChoices(items)
+		return err
 	}
 
 	prompt := promptui.Select{
@@ -310,8 +736,261 @@ func downloadFromAggregator() error {
 		return fmt.Errorf("selection cancelled: %w", err)
 	}
 
-	selectedProvider := aggregator.CSAFProviders[choiceIndex]
-	return downloadFromProviderURL(selectedProvider.Metadata.URL)
+	return downloadFromAvailableProvider(providers[choiceIndex])
+}
+
+// downloadFromAvailableProvider downloads from a provider listed by an aggregator, using its
+// mirrors if the provider cannot be reached
+func downloadFromAvailableProvider(provider download.AvailableProvider) error {
+	fmt.Printf("Fetching provider metadata from: %s\n", provider.Metadata.URL)
+	providerURL, providerMetadata, err := provider.FetchMetadata()
+	if err != nil {
+		return err
+	}
+	return downloadFromProviderMetadata(providerURL, providerMetadata)
+}
+
+// isTerminal reports whether stdin is a terminal that prompts can read answers from
+func isTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// requireTerminal returns an error if stdin is not a terminal, so that commands run from
+// cron jobs or pipelines fail instead of waiting for an answer to a prompt. hint names the
+// flags that replace the prompt.
+func requireTerminal(hint string) error {
+	if isTerminal() {
+		return nil
+	}
+	return fmt.Errorf("stdin is not a terminal, %s", hint)
+}
+
+// confirm asks the user to confirm an operation, unless --yes was given
+func confirm(label string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if err := requireTerminal("use --yes to confirm"); err != nil {
+		return false, err
+	}
+
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+	if _, err := prompt.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// selectItems returns the indexes of the items matched by selectors, in the order of the
+// items. A selector is the 1-based position of an item in the list, a regular expression
+// enclosed in slashes, or a name. Names and regular expressions are case-insensitive and are
+// matched against the item itself and the names returned for it. Every selector must match
+// at least one item.
+func selectItems(selectors, items []string, names func(i int) []string) ([]int, error) {
+	selected := make(map[int]struct{})
+	for _, selector := range selectors {
+		if position, err := strconv.Atoi(selector); err == nil {
+			if position < 1 || position > len(items) {
+				printChoices(items)
+				return nil, fmt.Errorf("position %d is out of range, expected 1 to %d", position, len(items))
+			}
+			selected[position-1] = struct{}{}
+			continue
+		}
+
+		match := func(candidate string) bool {
+			return strings.EqualFold(candidate, selector)
+		}
+		if len(selector) > 2 && strings.HasPrefix(selector, "/") && strings.HasSuffix(selector, "/") {
+			re, err := regexp.Compile("(?i)" + selector[1:len(selector)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %s: %w", selector, err)
+			}
+			match = re.MatchString
+		}
+
+		matched := false
+		for i, item := range items {
+			for _, candidate := range append([]string{item}, names(i)...) {
+				if candidate != "" && match(candidate) {
+					selected[i] = struct{}{}
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			printChoices(items)
+			return nil, fmt.Errorf("%q does not match any of the %d choices", selector, len(items))
+		}
+	}
+
+	indexes := make([]int, 0, len(selected))
+	for i := range selected {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
+
+// printChoices lists the choices a selector can match, numbered by position
+func printChoices(items []string) {
+	fmt.Println("Available choices:")
+	for i, item := range items {
+		fmt.Printf("  %d. %s\n", i+1, item)
+	}
+}
+
+// validateDocuments validates a single document or all documents in a directory or cached data set
+func validateDocuments(source string) error {
+	var tests []validate.Test
+	var err error
+	if len(testIDs) > 0 {
+		tests, err = validate.SelectTests(testIDs)
+	} else {
+		tests, err = validate.TestsForProfiles(profiles)
+	}
+	if err != nil {
+		return err
+	}
+	if spellChecker != "" {
+		checker, err := validate.NewCommandSpellChecker(spellChecker)
+		if err != nil {
+			return err
+		}
+		validate.SetSpellChecker(checker)
+	}
+	validator := validate.NewValidator(tests)
+
+	var results []validate.Result
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		results = append(results, validator.URL(source))
+	} else {
+		path := source
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// Not a local path, look for a cached data set of that name
+			path = filepath.Join(cache.DetermineCachePath(), source)
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("'%s' is not a file, directory or cached data set", source)
+			}
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			fmt.Printf("Validating CSAF documents in %s\n", path)
+			results, err = validator.Directory(path, func(done, total int) {
+				if done%100 == 0 || done == total {
+					fmt.Printf("Validated %d/%d documents\n", done, total)
+				}
+			})
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				return fmt.Errorf("no CSAF documents found in %s", path)
+			}
+			fmt.Println()
+		} else {
+			results = append(results, validator.File(path))
+		}
+	}
+
+	for _, result := range results {
+		printValidationResult(result, len(results) == 1)
+	}
+
+	summary := validate.Summarize(results)
+	if len(results) > 1 {
+		fmt.Printf("\nValidated %d documents: %d valid, %d invalid, %d unreadable (%d errors, %d warnings, %d info in total)\n",
+			summary.Documents, summary.Valid, summary.Invalid, summary.Failed, summary.Errors, summary.Warnings, summary.Infos)
+	}
+
+	if summary.Invalid > 0 || summary.Failed > 0 {
+		return fmt.Errorf("%d of %d documents failed validation", summary.Invalid+summary.Failed, summary.Documents)
+	}
+	return nil
+}
+
+// printValidationResult prints the violations of a document; documents without any
+// findings are only reported when validating a single document
+func printValidationResult(result validate.Result, verbose bool) {
+	if result.Err != nil {
+		fmt.Printf("%s: %v\n", result.Source, result.Err)
+		return
+	}
+
+	if len(result.Violations) == 0 {
+		if verbose {
+			fmt.Printf("%s is valid\n", result.Source)
+		}
+		return
+	}
+
+	status := "invalid"
+	if result.Valid() {
+		status = "valid"
+	}
+	fmt.Printf("%s is %s: %d errors, %d warnings, %d info\n", result.Source, status,
+		result.Count(validate.SeverityError), result.Count(validate.SeverityWarning), result.Count(validate.SeverityInfo))
+	for _, v := range result.Violations {
+		path := v.Path
+		if path == "" {
+			path = "/"
+		}
+		if v.Test != "" {
+			fmt.Printf("  %-7s [%s] %s: %s\n", v.Severity, v.Test, path, v.Message)
+		} else {
+			fmt.Printf("  %-7s %s: %s\n", v.Severity, path, v.Message)
+		}
+	}
+}
+
+// printTests lists all tests that can be selected with --test
+func printTests() {
+	for _, test := range validate.AllTests() {
+		fmt.Printf("%-10s %-8s %s\n", test.ID, test.Severity(), test.Name)
+	}
+}
+
+// verifyDataSet re-checks the hashes of all documents in a cached data set
+func verifyDataSet(dataSetName string) error {
+	fmt.Printf("Verifying data set: %s\n", dataSetName)
+	report, err := cache.VerifyDataSetHashes(dataSetName, func(done, total int) {
+		if done%1000 == 0 || done == total {
+			fmt.Printf("Verified %d/%d documents\n", done, total)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for path := range report.Failed {
+		failed = append(failed, path)
+	}
+	sort.Strings(failed)
+	for _, path := range failed {
+		fmt.Printf("  %s: %s\n", path, report.Failed[path])
+	}
+
+	fmt.Printf("%d documents match their hash, %d documents have no stored hash, %d documents failed\n",
+		report.Verified, len(report.Missing), len(report.Failed))
+
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d documents failed hash verification", len(report.Failed))
+	}
+	return nil
 }
 
 // listCacheDataSets lists all available cached CSAF data sets with their sizes
@@ -328,7 +1007,15 @@ func listCacheDataSets() error {
 
 	fmt.Printf("Available cached CSAF data sets:\n\n")
 	for _, ds := range dataSets {
-		fmt.Printf("%-20s %s\n", ds.Name, cache.FormatSize(ds.Size))
+		details := ds.TLPLabels
+		if ds.Partial {
+			details = append(details, "partial")
+		}
+		if len(details) > 0 {
+			fmt.Printf("%-20s %-10s %s\n", ds.Name, cache.FormatSize(ds.Size), strings.Join(details, ", "))
+		} else {
+			fmt.Printf("%-20s %s\n", ds.Name, cache.FormatSize(ds.Size))
+		}
 	}
 
 	return nil
@@ -369,19 +1056,14 @@ func clearAllDataSets() error {
 		fmt.Printf("  - %s (%s)\n", ds.Name, cache.FormatSize(ds.Size))
 	}
 
-	prompt := promptui.Prompt{
-		Label:     "Are you sure you want to continue",
-		IsConfirm: true,
-	}
-
-	_, err = prompt.Run()
+	confirmed, err := confirm("Are you sure you want to continue")
 	if err != nil {
-		if errors.Is(err, promptui.ErrAbort) {
-			fmt.Println("Operation cancelled")
-			return nil
-		}
 		return err
 	}
+	if !confirmed {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
 
 	if err := cache.ClearAllDataSets(); err != nil {
 		return err
@@ -415,19 +1097,14 @@ func clearOneDataSet(dataSetName string) error {
 
 	fmt.Printf("This will clear data set '%s' (%s)\n", targetDataSet.Name, cache.FormatSize(targetDataSet.Size))
 
-	prompt := promptui.Prompt{
-		Label:     "Are you sure you want to continue",
-		IsConfirm: true,
-	}
-
-	_, err = prompt.Run()
+	confirmed, err := confirm("Are you sure you want to continue")
 	if err != nil {
-		if errors.Is(err, promptui.ErrAbort) {
-			fmt.Println("Operation cancelled")
-			return nil
-		}
 		return err
 	}
+	if !confirmed {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
 
 	if err := cache.ClearDataSet(dataSetName); err != nil {
 		return err
@@ -439,6 +1116,10 @@ func clearOneDataSet(dataSetName string) error {
 
 // interactiveClear provides interactive multi-select for clearing cached CSAF data sets
 func interactiveClear() error {
+	if err := requireTerminal("name a data set or use --all instead of --interactive"); err != nil {
+		return err
+	}
+
 	dataSets, err := cache.ListDataSets()
 	if err != nil {
 		return err
@@ -538,19 +1219,14 @@ func interactiveClear() error {
 	}
 	fmt.Printf("Total size: %s\n", cache.FormatSize(totalSize))
 
-	confirmPrompt := promptui.Prompt{
-		Label:     "Are you sure you want to clear these data sets",
-		IsConfirm: true,
-	}
-
-	_, err = confirmPrompt.Run()
+	confirmed, err := confirm("Are you sure you want to clear these data sets")
 	if err != nil {
-		if errors.Is(err, promptui.ErrAbort) {
-			fmt.Println("Operation cancelled")
-			return nil
-		}
 		return err
 	}
+	if !confirmed {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
 
 	// Clear selected data sets
 	var clearErrors []error
@@ -608,7 +1284,7 @@ func syncDataSet(dataSetName string) error {
 
 	fmt.Printf("Data set: %s\n", dataSetName)
 	fmt.Printf("Syncing data set from: %s\n", sourceURL)
-	targetPath, err := download.FromDirectoryURL(sourceURL)
+	targetPath, err := download.Sync(sourceURL, downloadOptions())
 	if err != nil {
 		return fmt.Errorf("failed to sync data set: %w", err)
 	}
@@ -656,19 +1332,14 @@ func syncAllDataSets() error {
 		}
 	}
 
-	prompt := promptui.Prompt{
-		Label:     "Are you sure you want to continue",
-		IsConfirm: true,
-	}
-
-	_, err = prompt.Run()
+	confirmed, err := confirm("Are you sure you want to continue")
 	if err != nil {
-		if errors.Is(err, promptui.ErrAbort) {
-			fmt.Println("Sync cancelled")
-			return nil
-		}
 		return err
 	}
+	if !confirmed {
+		fmt.Println("Sync cancelled")
+		return nil
+	}
 
 	var syncErrors []error
 	var successCount int
@@ -682,7 +1353,7 @@ func syncAllDataSets() error {
 			continue
 		}
 
-		_, err = download.FromDirectoryURL(sourceURL)
+		_, err = download.Sync(sourceURL, downloadOptions())
 		if err != nil {
 			syncErrors = append(syncErrors, fmt.Errorf("failed to sync %s: %w", dsName, err))
 		} else {
@@ -709,6 +1380,10 @@ func syncAllDataSets() error {
 
 // interactiveSync provides interactive multi-select for syncing cached CSAF data sets
 func interactiveSync() error {
+	if err := requireTerminal("name a data set or use --all instead of --interactive"); err != nil {
+		return err
+	}
+
 	dataSets, err := cache.ListDataSets()
 	if err != nil {
 		return err
@@ -822,19 +1497,14 @@ func interactiveSync() error {
 		fmt.Printf("  - %s (%s)\n", ds.Name, cache.FormatSize(ds.Size))
 	}
 
-	confirmPrompt := promptui.Prompt{
-		Label:     "Are you sure you want to sync these data sets",
-		IsConfirm: true,
-	}
-
-	_, err = confirmPrompt.Run()
+	confirmed, err := confirm("Are you sure you want to sync these data sets")
 	if err != nil {
-		if errors.Is(err, promptui.ErrAbort) {
-			fmt.Println("Operation cancelled")
-			return nil
-		}
 		return err
 	}
+	if !confirmed {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
 
 	// Sync selected data sets
 	var syncErrors []error
@@ -857,7 +1527,7 @@ func interactiveSync() error {
 			continue
 		}
 
-		_, err = download.FromDirectoryURL(sourceURL)
+		_, err = download.Sync(sourceURL, downloadOptions())
 		if err != nil {
 			syncErrors = append(syncErrors, fmt.Errorf("failed to sync %s: %w", ds.Name, err))
 		} else {
@@ -881,3 +1551,151 @@ func interactiveSync() error {
 
 	return nil
 }
+
+// syncConfiguredSources syncs the named sources of the configuration file, or all of them
+func syncConfiguredSources(cmd *cobra.Command, names []string) error {
+	if len(appConfig.Sources) == 0 {
+		return fmt.Errorf("no sources configured, add them under sources: in the configuration file")
+	}
+
+	var sources []*config.Source
+	if len(names) == 0 {
+		for i := range appConfig.Sources {
+			sources = append(sources, &appConfig.Sources[i])
+		}
+	}
+	for _, name := range names {
+		source, ok := appConfig.Find(name)
+		if !ok {
+			var configured []string
+			for _, source := range appConfig.Sources {
+				configured = append(configured, source.Name)
+			}
+			return fmt.Errorf("unknown source %q, configured sources: %s", name, strings.Join(configured, ", "))
+		}
+		sources = append(sources, source)
+	}
+
+	var failed []string
+	for i, source := range sources {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Syncing source %s (%s)\n", source.Name, source.URL())
+		if err := syncConfiguredSource(cmd, source); err != nil {
+			fmt.Printf("Failed to sync %s: %v\n", source.Name, err)
+			failed = append(failed, source.Name)
+		}
+	}
+
+	fmt.Printf("\nSynced %d out of %d sources\n", len(sources)-len(failed), len(sources))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to sync %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// syncConfiguredSource brings the data sets of a configured source up to date. For providers,
+// every distribution that passes the TLP filter of the source is a data set of its own.
+func syncConfiguredSource(cmd *cobra.Command, source *config.Source) error {
+	opts := downloadOptions()
+	if source.Concurrency > 0 && !cmd.Flags().Changed("concurrency") {
+		opts.Concurrency = source.Concurrency
+	}
+	if source.MaxPerHost > 0 && !cmd.Flags().Changed("max-per-host") {
+		opts.MaxPerHost = source.MaxPerHost
+	}
+
+	var sources []download.Source
+	switch {
+	case source.Directory != "":
+		sources = []download.Source{{URL: source.Directory}}
+	case source.Feed != "":
+		sources = []download.Source{{URL: source.Feed, Feed: true}}
+	default:
+		providerURL, providerMetadata, err := configuredProvider(source)
+		if err != nil {
+			return err
+		}
+		opts.ProviderURL = providerURL
+
+		urlSet := make(map[string]struct{})
+		for _, dist := range providerMetadata.Distributions {
+			distSources, err := dist.GetSources()
+			if err != nil {
+				fmt.Printf("Warning: failed to get sources for distribution: %v\n", err)
+				continue
+			}
+			for _, distSource := range distSources {
+				if _, ok := urlSet[distSource.URL]; ok {
+					continue
+				}
+				urlSet[distSource.URL] = struct{}{}
+				if !source.AllowsTLP(distSource.TLPLabel) {
+					fmt.Printf("Skipping %s, not selected by the TLP filter\n", distSource)
+					continue
+				}
+				sources = append(sources, distSource)
+			}
+		}
+		if len(sources) == 0 {
+			return fmt.Errorf("no distributions of %s pass the TLP filter", providerURL)
+		}
+	}
+
+	var failed int
+	for _, dataSource := range sources {
+		if lastSync, ok := syncedWithin(dataSource.URL, source.SyncTTL); ok && !forceSync {
+			fmt.Printf("Skipping %s, synced at %s which is within the sync TTL of %s\n",
+				dataSource.URL, lastSync.Format(time.RFC3339), source.SyncTTL)
+			continue
+		}
+		if err := downloadFromSource(dataSource, opts); err != nil {
+			fmt.Printf("Failed to sync %s: %v\n", dataSource.URL, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d data sets failed", failed, len(sources))
+	}
+	return nil
+}
+
+// configuredProvider fetches the provider metadata of a source, discovering it first if the
+// source names a domain
+func configuredProvider(source *config.Source) (string, *download.ProviderMetadata, error) {
+	if source.Domain == "" {
+		fmt.Printf("Fetching provider metadata from: %s\n", source.Provider)
+		providerMetadata, err := download.FromProviderURL(source.Provider)
+		return source.Provider, providerMetadata, err
+	}
+
+	discovery, err := download.DiscoverProvider(source.Domain)
+	var discoveryErr *download.DiscoveryError
+	if errors.As(err, &discoveryErr) {
+		printDiscoveryAttempts(discoveryErr.Attempts)
+		return "", nil, fmt.Errorf("no CSAF provider metadata found for %s", source.Domain)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	fmt.Printf("Found provider metadata via %s: %s\n", discovery.Method(), discovery.ProviderURL)
+	return discovery.ProviderURL, discovery.Metadata, nil
+}
+
+// syncedWithin returns the last sync of the data set downloaded from sourceURL if that sync
+// was complete and happened less than ttl ago
+func syncedWithin(sourceURL string, ttl time.Duration) (time.Time, bool) {
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	dataSetPath := filepath.Join(cache.DetermineCachePath(), download.DataSetName(sourceURL))
+	metadata, err := cache.LoadSyncMetadata(dataSetPath)
+	if err != nil || metadata == nil || len(metadata.FailedDownloads) > 0 {
+		return time.Time{}, false
+	}
+	if time.Since(metadata.LastSync) >= ttl {
+		return time.Time{}, false
+	}
+	return metadata.LastSync, true
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testAdvisory is a schema-valid document with optional and informative findings and a
+// placeholder for its revision number
+const testAdvisory = `{
+  "document": {
+    "category": "csaf_base",
+    "csaf_version": "2.0",
+    "publisher": {"category": "vendor", "name": "Example Company", "namespace": "https://example.com"},
+    "title": "Example advisory",
+    "tracking": {
+      "current_release_date": "2024-01-01T10:00:00Z",
+      "id": "EXAMPLE-2024-0001",
+      "initial_release_date": "2024-01-01T10:00:00Z",
+      "revision_history": [{"date": "2024-01-01T10:00:00Z", "number": "%s", "summary": "Initial version"}],
+      "status": "final",
+      "version": "1"
+    }
+  },
+  "product_tree": {"full_product_names": [{"name": "Example Server 1.0", "product_id": "CSAFPID-1"}]},
+  "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1"]}}]
+}`
+
+func TestValidateDocumentsExitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		// revision is the number of the only revision, which must match the version "1"
+		revision string
+		wantErr  bool
+	}{
+		{name: "only optional and informative findings", profiles: []string{"all"}, revision: "1"},
+		{name: "optional findings", profiles: []string{"optional"}, revision: "1"},
+		{name: "failed mandatory test", profiles: []string{"all"}, revision: "2", wantErr: true},
+		{name: "mandatory test not selected", profiles: []string{"informative"}, revision: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "advisory.json")
+			document := fmt.Sprintf(testAdvisory, tt.revision)
+			if err := os.WriteFile(path, []byte(document), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			previous := profiles
+			profiles = tt.profiles
+			t.Cleanup(func() { profiles = previous })
+
+			err := validateDocuments(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDocuments() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,13 +5,49 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
+// HTTPValidators are the ETag and Last-Modified values of a fetched resource. They are
+// sent back in conditional requests to learn whether the resource changed.
+type HTTPValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// SourceROLIE is the source type of data sets downloaded from a ROLIE feed
+const SourceROLIE = "rolie"
+
 // SyncMetadata tracks when the cache was last synchronized and its source
 type SyncMetadata struct {
 	LastSync  time.Time `json:"last_sync"`
 	SourceURL string    `json:"source_url"`
+	// SourceType is SourceROLIE if SourceURL is a ROLIE feed and empty for CSAF directories
+	SourceType string `json:"source_type,omitempty"`
+	// ProviderURL is the provider-metadata.json whose OpenPGP keys sign the documents
+	ProviderURL string `json:"provider_url,omitempty"`
+	// ArchiveURL is the archive named by archive_latest.txt when the data set was last
+	// downloaded in full
+	ArchiveURL string `json:"archive_url,omitempty"`
+	// SignatureFailures maps documents that failed signature verification to the reason
+	SignatureFailures map[string]string `json:"signature_failures,omitempty"`
+	// Quarantined maps documents that failed hash verification to the reason
+	Quarantined map[string]string `json:"quarantined,omitempty"`
+	// FailedDownloads lists documents that could not be downloaded and are retried on the next sync
+	FailedDownloads []string `json:"failed_downloads,omitempty"`
+	// Partial is set if the data set was first downloaded without the documents in
+	// FailedDownloads. It is cleared once they have all been retried successfully.
+	Partial bool `json:"partial,omitempty"`
+	// TLPLabels maps documents to their TLP label, empty for documents without a label
+	TLPLabels map[string]string `json:"tlp_labels,omitempty"`
+	// Validators maps the URLs of changes.csv, index.txt and archive_latest.txt, or of the ROLIE
+	// feed, to the validators returned by the last successful fetch
+	Validators map[string]HTTPValidators `json:"validators,omitempty"`
+	// Removed maps documents that are no longer listed in index.txt or the feed but were kept in the
+	// cache to the time they were first found missing
+	Removed map[string]time.Time `json:"removed,omitempty"`
 }
 
 // LoadSyncMetadata reads the sync metadata file from the cache directory
@@ -112,6 +148,10 @@ type DataSetInfo struct {
 	Name string
 	Path string
 	Size int64
+	// TLPLabels lists the distinct TLP labels of the documents in the data set
+	TLPLabels []string
+	// Partial is set if documents of the data set failed to download and are still missing
+	Partial bool
 }
 
 // ListDataSets returns all available cached CSAF data sets with their sizes
@@ -130,7 +170,8 @@ func ListDataSets() ([]DataSetInfo, error) {
 
 	var dataSets []DataSetInfo
 	for _, entry := range entries {
-		if entry.IsDir() {
+		// Hidden directories hold staging data of running or interrupted downloads
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
 			dataSetPath := filepath.Join(cachePath, entry.Name())
 			size, err := calculateDirSize(dataSetPath)
 			if err != nil {
@@ -138,17 +179,42 @@ func ListDataSets() ([]DataSetInfo, error) {
 				size = 0
 			}
 
-			dataSets = append(dataSets, DataSetInfo{
+			info := DataSetInfo{
 				Name: entry.Name(),
 				Path: dataSetPath,
 				Size: size,
-			})
+			}
+			if metadata, err := LoadSyncMetadata(dataSetPath); err == nil && metadata != nil {
+				info.TLPLabels = dataSetTLPLabels(metadata)
+				info.Partial = metadata.Partial
+			}
+			dataSets = append(dataSets, info)
 		}
 	}
 
 	return dataSets, nil
 }
 
+// dataSetTLPLabels returns the sorted distinct TLP labels recorded for a data set.
+// Documents without a label are listed as "unlabeled".
+func dataSetTLPLabels(metadata *SyncMetadata) []string {
+	seen := make(map[string]struct{})
+	var labels []string
+	for _, label := range metadata.TLPLabels {
+		if label == "" {
+			label = "unlabeled"
+		} else {
+			label = "TLP:" + label
+		}
+		if _, ok := seen[label]; !ok {
+			seen[label] = struct{}{}
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
 // ClearDataSet removes a specific cached CSAF data set
 func ClearDataSet(dataSetName string) error {
 	cachePath := DetermineCachePath()
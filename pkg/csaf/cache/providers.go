@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// providersDir is the hidden directory of the cache that keeps the provider-metadata.json
+// files of known providers, so that they can be fetched with conditional requests
+const providersDir = ".providers"
+
+// cachedProviderMetadata is a provider-metadata.json kept in the cache together with the
+// validators it was served with
+type cachedProviderMetadata struct {
+	Validators HTTPValidators  `json:"validators"`
+	Metadata   json.RawMessage `json:"metadata"`
+}
+
+// providerMetadataPath returns the file a provider-metadata.json is kept in
+func providerMetadataPath(name string) string {
+	return filepath.Join(DetermineCachePath(), providersDir, name)
+}
+
+// LoadProviderMetadata returns a provider-metadata.json kept in the cache and the validators
+// to revalidate it with. A provider that is not in the cache yields no data.
+func LoadProviderMetadata(name string) ([]byte, HTTPValidators, error) {
+	data, err := os.ReadFile(providerMetadataPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, HTTPValidators{}, nil
+		}
+		return nil, HTTPValidators{}, fmt.Errorf("failed to read cached provider metadata: %w", err)
+	}
+
+	var cached cachedProviderMetadata
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, HTTPValidators{}, fmt.Errorf("failed to parse cached provider metadata: %w", err)
+	}
+	return cached.Metadata, cached.Validators, nil
+}
+
+// SaveProviderMetadata keeps a provider-metadata.json in the cache. Without validators the
+// file cannot be revalidated, so it is removed from the cache instead.
+func SaveProviderMetadata(name string, metadata []byte, validators HTTPValidators) error {
+	metadataPath := providerMetadataPath(name)
+	if validators == (HTTPValidators{}) {
+		if err := os.Remove(metadataPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cached provider metadata: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(cachedProviderMetadata{Validators: validators, Metadata: metadata})
+	if err != nil {
+		return fmt.Errorf("failed to marshal provider metadata: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(metadataPath), 0755); err != nil {
+		return fmt.Errorf("failed to create provider metadata directory: %w", err)
+	}
+	if err := os.WriteFile(metadataPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cached provider metadata: %w", err)
+	}
+	return nil
+}
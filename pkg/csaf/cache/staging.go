@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Prefixes of the hidden directories used while a data set is replaced. They start with a
+// dot, so they are never listed as data sets.
+const (
+	stagingPrefix  = ".staging-"
+	previousPrefix = ".previous-"
+)
+
+// CreateStagingDir creates an empty directory in the cache for a full download that
+// replaces the named data set once it succeeds
+func CreateStagingDir(dataSetName string) (string, error) {
+	cachePath, err := EnsureCachePath()
+	if err != nil {
+		return "", err
+	}
+	stagingPath, err := os.MkdirTemp(cachePath, stagingPrefix+dataSetName+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return stagingPath, nil
+}
+
+// CommitStagingDir replaces a data set with a completed staging directory. The previous data
+// set is moved aside first and only removed once the staging directory is in place; if the
+// second rename fails, the previous data set is restored.
+func CommitStagingDir(stagingPath, dataSetPath string) error {
+	previousPath := ""
+	if _, err := os.Stat(dataSetPath); err == nil {
+		previousPath = filepath.Join(filepath.Dir(dataSetPath), previousPrefix+filepath.Base(dataSetPath))
+		os.RemoveAll(previousPath)
+		if err := os.Rename(dataSetPath, previousPath); err != nil {
+			return fmt.Errorf("failed to move previous data set aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(stagingPath, dataSetPath); err != nil {
+		if previousPath != "" {
+			os.Rename(previousPath, dataSetPath)
+		}
+		return fmt.Errorf("failed to move staging directory into place: %w", err)
+	}
+
+	if previousPath != "" {
+		if err := os.RemoveAll(previousPath); err != nil {
+			fmt.Printf("Warning: failed to remove previous data set %s: %v\n", previousPath, err)
+		}
+	}
+	return nil
+}
+
+// CleanStagingDirs removes the staging directories that interrupted downloads of the named
+// data set left behind. A previous data set that was moved aside but not replaced is restored.
+func CleanStagingDirs(dataSetName string) error {
+	cachePath := DetermineCachePath()
+	entries, err := os.ReadDir(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	dataSetPath := filepath.Join(cachePath, dataSetName)
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(cachePath, name)
+		switch {
+		case isStagingDir(name, dataSetName):
+			fmt.Printf("Removing staging directory of an interrupted download: %s\n", name)
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove staging directory: %w", err)
+			}
+		case name == previousPrefix+dataSetName:
+			if _, err := os.Stat(dataSetPath); os.IsNotExist(err) {
+				fmt.Printf("Restoring data set %s from an interrupted replacement\n", dataSetName)
+				if err := os.Rename(path, dataSetPath); err != nil {
+					return fmt.Errorf("failed to restore previous data set: %w", err)
+				}
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove previous data set: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// CleanPartialArchives removes the partial archives of the named data set that are kept next
+// to it for resuming, except for archiveName and its hash and validator files. An archive
+// that is no longer named by archive_latest.txt can never be resumed. If archiveName is
+// empty, all partial archives of the data set are removed.
+func CleanPartialArchives(dataSetName, archiveName string) error {
+	cachePath := DetermineCachePath()
+	entries, err := os.ReadDir(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	// Data sets whose names extend this one with a dot own the archives that start with their names
+	var others []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), dataSetName+".") {
+			others = append(others, entry.Name()+".")
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, dataSetName+".") {
+			continue
+		}
+		if archiveName != "" && (name == archiveName || strings.HasPrefix(name, archiveName+".")) {
+			continue
+		}
+		if slices.ContainsFunc(others, func(other string) bool { return strings.HasPrefix(name, other) }) {
+			continue
+		}
+		fmt.Printf("Removing partial archive that is no longer current: %s\n", name)
+		if err := os.Remove(filepath.Join(cachePath, name)); err != nil {
+			return fmt.Errorf("failed to remove partial archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// isStagingDir reports whether a cache entry is a staging directory of the named data set.
+// The random suffix added by CreateStagingDir consists of digits only, which tells the
+// staging directories of data sets whose names share a prefix apart.
+func isStagingDir(name, dataSetName string) bool {
+	suffix, ok := strings.CutPrefix(name, stagingPrefix+dataSetName+"-")
+	if !ok || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
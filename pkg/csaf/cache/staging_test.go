@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeDataSet creates a directory in the cache that holds a single file with the given content
+func writeDataSet(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "metadata.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertDataSet checks the content of a directory created by writeDataSet
+func assertDataSet(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(path, "metadata.json"))
+	if err != nil {
+		t.Fatalf("data set %s: %v", path, err)
+	}
+	if string(data) != want {
+		t.Errorf("data set %s = %q, want %q", path, data, want)
+	}
+}
+
+// cacheEntries returns the sorted names of the entries of the cache directory
+func cacheEntries(t *testing.T, cachePath string) string {
+	t.Helper()
+	entries, err := os.ReadDir(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func TestCreateStagingDir(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache")
+	t.Setenv("CSAFX_CACHE_DIR", cachePath)
+
+	stagingPath, err := CreateStagingDir("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(stagingPath) != cachePath {
+		t.Errorf("CreateStagingDir() = %s, want a directory in %s", stagingPath, cachePath)
+	}
+	if !isStagingDir(filepath.Base(stagingPath), "example.com") {
+		t.Errorf("CreateStagingDir() = %s, not recognized as a staging directory", stagingPath)
+	}
+
+	// Staging directories are not listed as data sets
+	dataSets, err := ListDataSets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dataSets) != 0 {
+		t.Errorf("ListDataSets() = %+v, want no data sets", dataSets)
+	}
+}
+
+func TestCommitStagingDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous bool
+	}{
+		{name: "first download"},
+		{name: "replaces previous data set", previous: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cachePath := t.TempDir()
+			dataSetPath := filepath.Join(cachePath, "example.com")
+			if tt.previous {
+				writeDataSet(t, dataSetPath, "previous")
+			}
+			stagingPath := filepath.Join(cachePath, ".staging-example.com-123")
+			writeDataSet(t, stagingPath, "new")
+
+			if err := CommitStagingDir(stagingPath, dataSetPath); err != nil {
+				t.Fatal(err)
+			}
+			assertDataSet(t, dataSetPath, "new")
+			if got := cacheEntries(t, cachePath); got != "example.com" {
+				t.Errorf("cache entries = %s, want only the data set", got)
+			}
+		})
+	}
+}
+
+func TestCommitStagingDirRestoresPreviousDataSet(t *testing.T) {
+	cachePath := t.TempDir()
+	dataSetPath := filepath.Join(cachePath, "example.com")
+	writeDataSet(t, dataSetPath, "previous")
+
+	// A staging directory that cannot be moved into place
+	err := CommitStagingDir(filepath.Join(cachePath, ".staging-example.com-123"), dataSetPath)
+	if err == nil {
+		t.Fatal("CommitStagingDir() succeeded without a staging directory")
+	}
+	assertDataSet(t, dataSetPath, "previous")
+	if got := cacheEntries(t, cachePath); got != "example.com" {
+		t.Errorf("cache entries = %s, want only the data set", got)
+	}
+}
+
+func TestCleanStagingDirs(t *testing.T) {
+	tests := []struct {
+		name string
+		// dataSet is the content of the data set, empty if it is missing
+		dataSet string
+		want    string
+	}{
+		{name: "previous data set replaced", dataSet: "new", want: "new"},
+		{name: "interrupted replacement", want: "previous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cachePath := t.TempDir()
+			t.Setenv("CSAFX_CACHE_DIR", cachePath)
+			dataSetPath := filepath.Join(cachePath, "example.com")
+			if tt.dataSet != "" {
+				writeDataSet(t, dataSetPath, tt.dataSet)
+			}
+			writeDataSet(t, filepath.Join(cachePath, ".previous-example.com"), "previous")
+			writeDataSet(t, filepath.Join(cachePath, ".staging-example.com-123"), "staging")
+			// Staging directories of other data sets, one whose name shares the prefix
+			writeDataSet(t, filepath.Join(cachePath, ".staging-example.com-csaf-456"), "other")
+			writeDataSet(t, filepath.Join(cachePath, ".staging-example.org-789"), "other")
+
+			if err := CleanStagingDirs("example.com"); err != nil {
+				t.Fatal(err)
+			}
+			assertDataSet(t, dataSetPath, tt.want)
+			want := ".staging-example.com-csaf-456 .staging-example.org-789 example.com"
+			if got := cacheEntries(t, cachePath); got != want {
+				t.Errorf("cache entries = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCleanStagingDirsWithoutCache(t *testing.T) {
+	t.Setenv("CSAFX_CACHE_DIR", filepath.Join(t.TempDir(), "missing"))
+	if err := CleanStagingDirs("example.com"); err != nil {
+		t.Errorf("CleanStagingDirs() error = %v", err)
+	}
+}
+
+func TestIsStagingDir(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{".staging-example.com-123", true},
+		{".staging-example.com-", false},
+		{".staging-example.com-csaf-123", false},
+		{".staging-example.com", false},
+		{".previous-example.com", false},
+		{"example.com", false},
+	}
+	for _, tt := range tests {
+		if got := isStagingDir(tt.name, "example.com"); got != tt.want {
+			t.Errorf("isStagingDir(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCleanPartialArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive string
+		want    string
+	}{
+		{
+			name:    "current archive kept",
+			archive: "example.com.archive-2.tar.gz",
+			want: "example.com example.com.archive-2.tar.gz example.com.archive-2.tar.gz.validator " +
+				"example.com.au example.com.au.archive-1.tar.gz example.org.archive-1.tar.gz",
+		},
+		{
+			name: "no archive",
+			want: "example.com example.com.au example.com.au.archive-1.tar.gz example.org.archive-1.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cachePath := t.TempDir()
+			t.Setenv("CSAFX_CACHE_DIR", cachePath)
+			writeDataSet(t, filepath.Join(cachePath, "example.com"), "data set")
+			writeDataSet(t, filepath.Join(cachePath, "example.com.au"), "data set")
+			for _, name := range []string{
+				"example.com.archive-1.tar.gz",
+				"example.com.archive-1.tar.gz.sha512",
+				"example.com.archive-1.tar.gz.validator",
+				"example.com.archive-2.tar.gz",
+				"example.com.archive-2.tar.gz.validator",
+				// Partial archives of other data sets
+				"example.com.au.archive-1.tar.gz",
+				"example.org.archive-1.tar.gz",
+			} {
+				if err := os.WriteFile(filepath.Join(cachePath, name), []byte("partial"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := CleanPartialArchives("example.com", tt.archive); err != nil {
+				t.Fatal(err)
+			}
+			if got := cacheEntries(t, cachePath); got != tt.want {
+				t.Errorf("cache entries = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// QuarantineDir is the directory within a data set that holds documents which failed hash verification
+const QuarantineDir = ".quarantine"
+
+// HashAlgorithm is a hash published by CSAF providers in a sidecar file next to each document
+type HashAlgorithm struct {
+	Name      string
+	Extension string
+	New       func() hash.Hash
+}
+
+// HashAlgorithms lists the supported hash sidecar files, strongest first
+var HashAlgorithms = []HashAlgorithm{
+	{"SHA-512", ".sha512", sha512.New},
+	{"SHA-256", ".sha256", sha256.New},
+}
+
+// ErrNoHash is returned when no hash file is stored next to a document
+var ErrNoHash = errors.New("no .sha512 or .sha256 file found")
+
+// HashMismatchError is returned when a document does not match its stored hash
+type HashMismatchError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *HashMismatchError) Error() string {
+	return fmt.Sprintf("%s mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// parseHashFile extracts the hex digest from a hash file in the "<digest>  <filename>" format of sha256sum
+func parseHashFile(data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty hash file")
+	}
+	digest := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", fmt.Errorf("invalid digest %q", fields[0])
+	}
+	return digest, nil
+}
+
+// VerifyFileHash checks a document against the strongest hash file stored next to it
+func VerifyFileHash(path string) error {
+	for _, algorithm := range HashAlgorithms {
+		data, err := os.ReadFile(path + algorithm.Extension)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read hash file: %w", err)
+		}
+
+		expected, err := parseHashFile(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filepath.Base(path+algorithm.Extension), err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open document: %w", err)
+		}
+		defer file.Close()
+
+		h := algorithm.New()
+		if _, err := io.Copy(h, file); err != nil {
+			return fmt.Errorf("failed to hash document: %w", err)
+		}
+
+		actual := hex.EncodeToString(h.Sum(nil))
+		if actual != expected {
+			return &HashMismatchError{Algorithm: algorithm.Name, Expected: expected, Actual: actual}
+		}
+		return nil
+	}
+	return ErrNoHash
+}
+
+// ListDocuments returns the paths of all CSAF documents in a data set relative to its
+// directory, skipping the sync metadata and quarantined documents
+func ListDocuments(dataSetPath string) ([]string, error) {
+	var documents []string
+	err := filepath.WalkDir(dataSetPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == QuarantineDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".json") || path == filepath.Join(dataSetPath, "metadata.json") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dataSetPath, path)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents in %s: %w", dataSetPath, err)
+	}
+
+	sort.Strings(documents)
+	return documents, nil
+}
+
+// HashReport summarizes the offline hash verification of a data set
+type HashReport struct {
+	Verified int
+	// Missing lists documents without a stored hash file
+	Missing []string
+	// Failed maps documents that do not match their hash, or could not be checked, to the reason
+	Failed map[string]string
+}
+
+// VerifyDataSetHashes re-checks every document of a cached data set against the hash files stored next to it
+func VerifyDataSetHashes(dataSetName string, progress func(done, total int)) (*HashReport, error) {
+	dataSetPath := filepath.Join(DetermineCachePath(), dataSetName)
+	if _, err := os.Stat(dataSetPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("data set '%s' does not exist", dataSetName)
+	}
+
+	documents, err := ListDocuments(dataSetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &HashReport{Failed: make(map[string]string)}
+	for i, document := range documents {
+		err := VerifyFileHash(filepath.Join(dataSetPath, filepath.FromSlash(document)))
+		switch {
+		case err == nil:
+			report.Verified++
+		case errors.Is(err, ErrNoHash):
+			report.Missing = append(report.Missing, document)
+		default:
+			report.Failed[document] = err.Error()
+		}
+
+		if progress != nil {
+			progress(i+1, len(documents))
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is the environment variable that overrides the location of the configuration file
+const FileEnv = "CSAFX_CONFIG"
+
+// TLPLabels are the TLP labels a source can be filtered by
+var TLPLabels = []string{"WHITE", "GREEN", "AMBER", "RED"}
+
+// Config is the csafx configuration file
+type Config struct {
+	// Aggregators are the aggregator.json URLs providers are selected from
+	Aggregators []string `yaml:"aggregators,omitempty"`
+	// Sources are the providers, directories and feeds that csafx sync keeps up to date
+	Sources []Source `yaml:"sources,omitempty"`
+}
+
+// Source is a tracked provider, CSAF directory or ROLIE feed. Exactly one of Provider,
+// Domain, Directory and Feed is set.
+type Source struct {
+	// Name and Aliases identify the source on the command line
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases,omitempty"`
+	// Provider is the URL of a provider-metadata.json file
+	Provider string `yaml:"provider,omitempty"`
+	// Domain is a domain name whose provider-metadata.json is discovered
+	Domain string `yaml:"domain,omitempty"`
+	// Directory is the URL of a CSAF directory with an index.txt file
+	Directory string `yaml:"directory,omitempty"`
+	// Feed is the URL of a ROLIE feed
+	Feed string `yaml:"feed,omitempty"`
+	// TLP limits the distributions of a provider to those with these TLP labels
+	TLP []string `yaml:"tlp,omitempty"`
+	// Concurrency and MaxPerHost override the download defaults for this source
+	Concurrency int `yaml:"concurrency,omitempty"`
+	MaxPerHost  int `yaml:"max_per_host,omitempty"`
+	// SyncTTL skips syncing data sets that were synced successfully more recently
+	SyncTTL time.Duration `yaml:"sync_ttl,omitempty"`
+	// Credentials authenticate requests to the source. Their url defaults to the scheme
+	// and host of the source, except for domain sources that must set it.
+	Credentials *httpclient.Credentials `yaml:"credentials,omitempty"`
+}
+
+// URL returns the configured provider, domain, directory or feed
+func (s *Source) URL() string {
+	for _, u := range []string{s.Provider, s.Domain, s.Directory, s.Feed} {
+		if u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// Matches reports whether name is the name or one of the aliases of the source
+func (s *Source) Matches(name string) bool {
+	if strings.EqualFold(s.Name, name) {
+		return true
+	}
+	for _, alias := range s.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsTLP reports whether a distribution with the given TLP label passes the TLP filter.
+// Distributions whose label is unknown are allowed.
+func (s *Source) AllowsTLP(label string) bool {
+	return len(s.TLP) == 0 || label == "" || slices.Contains(s.TLP, strings.ToUpper(label))
+}
+
+// Find returns the source with the given name or alias
+func (c *Config) Find(name string) (*Source, bool) {
+	for i := range c.Sources {
+		if c.Sources[i].Matches(name) {
+			return &c.Sources[i], true
+		}
+	}
+	return nil, false
+}
+
+// Credentials returns the credentials configured for the sources
+func (c *Config) Credentials() []httpclient.Credentials {
+	var credentials []httpclient.Credentials
+	for _, source := range c.Sources {
+		if source.Credentials != nil {
+			credentials = append(credentials, *source.Credentials)
+		}
+	}
+	return credentials
+}
+
+// DefaultPath returns the configuration file used if no other file is configured
+func DefaultPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "csafx", "config.yaml")
+}
+
+// Load reads a YAML configuration file. References to environment variables such as
+// ${ACME_TOKEN} in the credentials of sources are expanded.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	names := make(map[string]string)
+	for i := range config.Sources {
+		source := &config.Sources[i]
+		if source.Name == "" {
+			return nil, fmt.Errorf("configuration file %s: source %d has no name", path, i+1)
+		}
+		if err := source.normalize(); err != nil {
+			return nil, fmt.Errorf("configuration file %s: source %s: %w", path, source.Name, err)
+		}
+		for _, name := range append([]string{source.Name}, source.Aliases...) {
+			key := strings.ToLower(name)
+			if other, ok := names[key]; ok {
+				return nil, fmt.Errorf("configuration file %s: name %q of source %s is already used by source %s",
+					path, name, source.Name, other)
+			}
+			names[key] = source.Name
+		}
+	}
+	return &config, nil
+}
+
+// normalize checks a source and fills in the defaults of its TLP filter and credentials
+func (s *Source) normalize() error {
+	set := 0
+	for _, u := range []string{s.Provider, s.Domain, s.Directory, s.Feed} {
+		if u != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of provider, domain, directory and feed must be set")
+	}
+
+	for i, label := range s.TLP {
+		label = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(label)), "TLP:")
+		if !slices.Contains(TLPLabels, label) {
+			return fmt.Errorf("unknown TLP label %q", s.TLP[i])
+		}
+		s.TLP[i] = label
+	}
+
+	if s.Credentials != nil {
+		if s.Credentials.URL == "" {
+			// Discovery can find the provider metadata of a domain on another host, such as
+			// csaf.data.security.<domain>, so the host of the domain cannot be assumed
+			if s.Domain != "" {
+				return fmt.Errorf("credentials of a domain source require a url")
+			}
+			u, err := url.Parse(s.URL())
+			if err != nil || u.Host == "" {
+				return fmt.Errorf("cannot derive the credentials url from %q", s.URL())
+			}
+			s.Credentials.URL = u.Scheme + "://" + u.Host + "/"
+		}
+		s.Credentials.ExpandEnv()
+	}
+	return nil
+}
+
+// Lookup loads the configuration from the given file, the file named by CSAFX_CONFIG or the
+// default configuration file, in that order. A missing default file yields an empty
+// configuration.
+func Lookup(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path == "" {
+		path = DefaultPath()
+		if _, err := os.Stat(path); err != nil {
+			return &Config{}, nil
+		}
+	}
+	return Load(path)
+}
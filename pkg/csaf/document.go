@@ -0,0 +1,117 @@
+// Package csaf provides Go types for the CSAF 2.0 document format as defined in
+// https://docs.oasis-open.org/csaf/csaf/v2.0/os/schemas/csaf_json_schema.json
+package csaf
+
+// Document categories defined by the CSAF profiles
+const (
+	CategoryBase             = "csaf_base"
+	CategorySecurityIncident = "csaf_security_incident_response"
+	CategoryInformational    = "csaf_informational_advisory"
+	CategorySecurityAdvisory = "csaf_security_advisory"
+	CategoryVEX              = "csaf_vex"
+)
+
+// Document represents a complete CSAF 2.0 document
+type Document struct {
+	Document        DocumentMetadata `json:"document"`
+	ProductTree     *ProductTree     `json:"product_tree,omitempty"`
+	Vulnerabilities []Vulnerability  `json:"vulnerabilities,omitempty"`
+}
+
+// DocumentMetadata contains the document-level metadata of a CSAF document
+type DocumentMetadata struct {
+	Acknowledgments   []Acknowledgment   `json:"acknowledgments,omitempty"`
+	AggregateSeverity *AggregateSeverity `json:"aggregate_severity,omitempty"`
+	Category          string             `json:"category"`
+	CSAFVersion       string             `json:"csaf_version"`
+	Distribution      *Distribution      `json:"distribution,omitempty"`
+	Lang              string             `json:"lang,omitempty"`
+	Notes             []Note             `json:"notes,omitempty"`
+	Publisher         Publisher          `json:"publisher"`
+	References        []Reference        `json:"references,omitempty"`
+	SourceLang        string             `json:"source_lang,omitempty"`
+	Title             string             `json:"title"`
+	Tracking          Tracking           `json:"tracking"`
+}
+
+// Acknowledgment recognizes contributors of the document or a vulnerability
+type Acknowledgment struct {
+	Names        []string `json:"names,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	URLs         []string `json:"urls,omitempty"`
+}
+
+// AggregateSeverity is the publisher's overall severity rating of the document
+type AggregateSeverity struct {
+	Namespace string `json:"namespace,omitempty"`
+	Text      string `json:"text"`
+}
+
+// Distribution describes restrictions on how the document may be shared
+type Distribution struct {
+	Text string `json:"text,omitempty"`
+	TLP  *TLP   `json:"tlp,omitempty"`
+}
+
+// TLP contains the Traffic Light Protocol label of the document
+type TLP struct {
+	Label string `json:"label"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Note holds additional information attached to the document or a vulnerability
+type Note struct {
+	Audience string `json:"audience,omitempty"`
+	Category string `json:"category"`
+	Text     string `json:"text"`
+	Title    string `json:"title,omitempty"`
+}
+
+// Publisher identifies the issuing party of the document
+type Publisher struct {
+	Category         string `json:"category"`
+	ContactDetails   string `json:"contact_details,omitempty"`
+	IssuingAuthority string `json:"issuing_authority,omitempty"`
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+}
+
+// Reference points to a resource related to the document or a vulnerability
+type Reference struct {
+	Category string `json:"category,omitempty"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+}
+
+// Tracking contains the information used to track the document over its lifetime
+type Tracking struct {
+	Aliases            []string   `json:"aliases,omitempty"`
+	CurrentReleaseDate string     `json:"current_release_date"`
+	Generator          *Generator `json:"generator,omitempty"`
+	ID                 string     `json:"id"`
+	InitialReleaseDate string     `json:"initial_release_date"`
+	RevisionHistory    []Revision `json:"revision_history"`
+	Status             string     `json:"status"`
+	Version            string     `json:"version"`
+}
+
+// Generator describes the tool that created the document
+type Generator struct {
+	Date   string `json:"date,omitempty"`
+	Engine Engine `json:"engine"`
+}
+
+// Engine identifies the software used to generate the document
+type Engine struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Revision is a single entry in the document's revision history
+type Revision struct {
+	Date          string `json:"date"`
+	LegacyVersion string `json:"legacy_version,omitempty"`
+	Number        string `json:"number"`
+	Summary       string `json:"summary"`
+}
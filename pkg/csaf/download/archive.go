@@ -0,0 +1,299 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+)
+
+const (
+	// maxArchiveStalls is how often an interrupted archive download is resumed without
+	// receiving any data before giving up
+	maxArchiveStalls = 5
+	// progressInterval is the time between two progress reports of an archive download
+	progressInterval = 5 * time.Second
+)
+
+// GetCSAFArchive downloads an archive to destination. A partial file left behind by an
+// earlier attempt is resumed with an HTTP Range request, and interrupted transfers are
+// resumed for as long as they make progress. Client errors such as 404 Not Found are
+// returned without retrying. The partial file is kept on failure so a later call can
+// continue where this one stopped.
+func GetCSAFArchive(url, destination string) error {
+	fmt.Printf("Downloading CSAF archive from %s\n", url)
+
+	stalls := 0
+	for {
+		before := fileSize(destination)
+		err := fetchArchiveRange(url, destination)
+		if err == nil {
+			break
+		}
+		var statusErr *archiveStatusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			return err
+		}
+
+		if fileSize(destination) > before {
+			stalls = 0
+		} else {
+			stalls++
+		}
+		if stalls >= maxArchiveStalls {
+			return fmt.Errorf("failed to download archive: %w", err)
+		}
+
+		wait := time.Duration(stalls) * 2 * time.Second
+		fmt.Printf("Archive download interrupted (%v), resuming at %s in %s\n",
+			err, cache.FormatSize(fileSize(destination)), wait)
+		time.Sleep(wait)
+	}
+
+	os.Remove(validatorPath(destination))
+	fmt.Printf("Saved CSAF archive to %s\n", destination)
+	return nil
+}
+
+// fileSize returns the size of a file, zero if it does not exist
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// validatorPath is the file that stores the ETag or Last-Modified value of a partial download
+func validatorPath(destination string) string {
+	return destination + ".validator"
+}
+
+// fetchArchiveRange requests the part of the archive that is missing from destination and
+// appends it. The If-Range header makes the server send the whole archive if it changed
+// since the partial file was started.
+func fetchArchiveRange(url, destination string) error {
+	offset := fileSize(destination)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator, err := os.ReadFile(validatorPath(destination)); err == nil && len(validator) > 0 {
+			req.Header.Set("If-Range", string(validator))
+		}
+	}
+
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var file *os.File
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return fmt.Errorf("server returned an unexpected range %q", resp.Header.Get("Content-Range"))
+		}
+		fmt.Printf("Resuming archive download at %s\n", cache.FormatSize(offset))
+		file, err = os.OpenFile(destination, os.O_WRONLY|os.O_APPEND, 0644)
+	case http.StatusOK:
+		if offset > 0 {
+			fmt.Println("Server does not support resuming or the archive changed, starting over")
+		}
+		offset = 0
+		saveValidator(destination, resp)
+		file, err = os.Create(destination)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is as large as the archive or larger. A complete file is kept,
+		// anything else is discarded and downloaded again.
+		if size, ok := contentRangeSize(resp.Header.Get("Content-Range")); ok && size == offset {
+			return nil
+		}
+		os.Remove(destination)
+		return fmt.Errorf("partial archive does not match the archive on the server")
+	default:
+		return &archiveStatusError{url: url, statusCode: resp.StatusCode}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := newTransferProgress(offset, total)
+	_, err = io.Copy(file, io.TeeReader(resp.Body, progress))
+	progress.print()
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if total >= 0 && fileSize(destination) != total {
+		return fmt.Errorf("received %s of %s", cache.FormatSize(fileSize(destination)), cache.FormatSize(total))
+	}
+	return nil
+}
+
+// archiveStatusError is returned for an archive request that failed with an HTTP status
+type archiveStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *archiveStatusError) Error() string {
+	return fmt.Sprintf("failed to download archive %s: HTTP %d", e.url, e.statusCode)
+}
+
+// retryable reports whether resuming may succeed later. Client errors such as 404 Not Found
+// or 403 Forbidden do not change by asking again, unlike 429 Too Many Requests and 5xx errors.
+func (e *archiveStatusError) retryable() bool {
+	return e.statusCode == http.StatusTooManyRequests || e.statusCode >= 500
+}
+
+// saveValidator stores the ETag or Last-Modified value of a full response, used to resume
+// only if the archive did not change. Weak ETags cannot be used with If-Range.
+func saveValidator(destination string, resp *http.Response) {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" {
+		os.Remove(validatorPath(destination))
+		return
+	}
+	os.WriteFile(validatorPath(destination), []byte(validator), 0644)
+}
+
+// contentRangeStart returns the first byte position of a "bytes start-end/size" Content-Range header
+func contentRangeStart(header string) (int64, bool) {
+	rangeSpec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rangeSpec, "-")
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(start, 10, 64)
+	return value, err == nil
+}
+
+// contentRangeSize returns the complete size of a Content-Range header such as "bytes */size"
+func contentRangeSize(header string) (int64, bool) {
+	_, size, ok := strings.Cut(header, "/")
+	if !ok || size == "*" {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(size, 10, 64)
+	return value, err == nil
+}
+
+// downloadArchive downloads an archive and checks it against its published hash. A resumed
+// download that fails the check is downloaded once more from the beginning, in case the
+// partial file was damaged.
+func downloadArchive(url, destination string) error {
+	resumed := fileSize(destination) > 0
+	if err := GetCSAFArchive(url, destination); err != nil {
+		return err
+	}
+
+	err := verifyArchive(url, destination)
+	if err != nil && resumed {
+		fmt.Printf("Resumed archive failed verification (%v), downloading it again\n", err)
+		removeArchive(destination)
+		if err := GetCSAFArchive(url, destination); err != nil {
+			return err
+		}
+		err = verifyArchive(url, destination)
+	}
+	if err != nil {
+		removeArchive(destination)
+		return err
+	}
+	return nil
+}
+
+// verifyArchive checks a downloaded archive against the .sha512 or .sha256 file published
+// next to it. Archives without a published hash are accepted with a warning.
+func verifyArchive(url, archivePath string) error {
+	removeSidecars(archivePath)
+	err := checkHash(url, archivePath)
+	if errors.Is(err, cache.ErrNoHash) {
+		fmt.Println("Warning: no hash published for the archive, it cannot be verified")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("archive verification failed: %w", err)
+	}
+	fmt.Println("Archive matches its published hash")
+	return nil
+}
+
+// removeArchive deletes a downloaded archive together with its hash and validator files
+func removeArchive(archivePath string) {
+	os.Remove(archivePath)
+	os.Remove(validatorPath(archivePath))
+	removeSidecars(archivePath)
+}
+
+// transferProgress periodically prints how much of a download is done, its rate and the
+// remaining time. It counts the bytes written to it.
+type transferProgress struct {
+	done      int64
+	total     int64
+	resumed   int64
+	start     time.Time
+	lastPrint time.Time
+}
+
+// newTransferProgress starts tracking a download of total bytes (-1 if unknown) of which
+// resumed bytes were downloaded before
+func newTransferProgress(resumed, total int64) *transferProgress {
+	now := time.Now()
+	return &transferProgress{done: resumed, total: total, resumed: resumed, start: now, lastPrint: now}
+}
+
+func (p *transferProgress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if time.Since(p.lastPrint) >= progressInterval {
+		p.print()
+	}
+	return len(b), nil
+}
+
+// print reports the current progress
+func (p *transferProgress) print() {
+	p.lastPrint = time.Now()
+
+	elapsed := time.Since(p.start).Seconds()
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.done-p.resumed) / elapsed
+	}
+
+	if p.total <= 0 {
+		fmt.Printf("Downloaded %s at %s/s\n", cache.FormatSize(p.done), cache.FormatSize(int64(rate)))
+		return
+	}
+
+	eta := "unknown"
+	if rate > 0 {
+		remaining := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+	fmt.Printf("Downloaded %s of %s (%.1f%%) at %s/s, %s remaining\n",
+		cache.FormatSize(p.done), cache.FormatSize(p.total), float64(p.done)*100/float64(p.total),
+		cache.FormatSize(int64(rate)), eta)
+}
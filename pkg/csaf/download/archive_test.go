@@ -0,0 +1,262 @@
+package download
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// archiveContent is the content of the archive served in the tests
+var archiveContent = strings.Repeat("0123456789", 1000)
+
+func TestGetCSAFArchiveResumesCutConnection(t *testing.T) {
+	noRetries(t)
+	const etag = `"archive-v1"`
+	var mu sync.Mutex
+	var ranges, ifRanges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+		mu.Unlock()
+
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("Range") == "" {
+			// Cut the connection after the first half of the archive
+			w.Header().Set("Content-Length", strconv.Itoa(len(archiveContent)))
+			fmt.Fprint(w, archiveContent[:len(archiveContent)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "archive.tar.gz", time.Time{}, strings.NewReader(archiveContent))
+	}))
+	defer server.Close()
+
+	destination := filepath.Join(t.TempDir(), "archive.tar.gz")
+	var err error
+	output := captureStdout(t, func() {
+		err = GetCSAFArchive(server.URL+"/archive.tar.gz", destination)
+	})
+	if err != nil {
+		t.Fatalf("GetCSAFArchive() error = %v, output:\n%s", err, output)
+	}
+	assertContent(t, destination, archiveContent)
+
+	wantRange := fmt.Sprintf("bytes=%d-", len(archiveContent)/2)
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != wantRange || ifRanges[1] != etag {
+		t.Errorf("requests with Range %q and If-Range %q, want a full request and one for %s with If-Range %s",
+			ranges, ifRanges, wantRange, etag)
+	}
+	if _, err := os.Stat(validatorPath(destination)); !os.IsNotExist(err) {
+		t.Errorf("validator file of a complete archive was kept: %v", err)
+	}
+}
+
+func TestGetCSAFArchiveClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			noRetries(t)
+			var mu sync.Mutex
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests++
+				mu.Unlock()
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			var err error
+			captureStdout(t, func() {
+				err = GetCSAFArchive(server.URL+"/archive.tar.gz", filepath.Join(t.TempDir(), "archive.tar.gz"))
+			})
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("HTTP %d", status)) {
+				t.Errorf("GetCSAFArchive() error = %v, want HTTP %d", err, status)
+			}
+			if requests != 1 {
+				t.Errorf("archive requested %d times, want once", requests)
+			}
+		})
+	}
+}
+
+func TestFetchArchiveRange(t *testing.T) {
+	const (
+		etag   = `"archive-v1"`
+		offset = 4000
+	)
+	changed := strings.Repeat("9876543210", 500)
+
+	tests := []struct {
+		name string
+		// respond answers the request for the missing part of the partial file
+		respond       func(w http.ResponseWriter, r *http.Request)
+		wantErr       string
+		want          string
+		wantValidator string
+	}{
+		{
+			name: "partial content",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(archiveContent)-1, len(archiveContent)))
+				w.WriteHeader(http.StatusPartialContent)
+				fmt.Fprint(w, archiveContent[offset:])
+			},
+			want:          archiveContent,
+			wantValidator: etag,
+		},
+		{
+			name: "unexpected range",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(archiveContent)-1, len(archiveContent)))
+				w.WriteHeader(http.StatusPartialContent)
+				fmt.Fprint(w, archiveContent)
+			},
+			wantErr:       "unexpected range",
+			want:          archiveContent[:offset],
+			wantValidator: etag,
+		},
+		{
+			name: "archive changed",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"archive-v2"`)
+				fmt.Fprint(w, changed)
+			},
+			want:          changed,
+			wantValidator: `"archive-v2"`,
+		},
+		{
+			name: "partial file is complete",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", offset))
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			},
+			want:          archiveContent[:offset],
+			wantValidator: etag,
+		},
+		{
+			name: "partial file larger than archive",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes */1000")
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			},
+			wantErr:       "partial archive does not match",
+			wantValidator: etag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetries(t)
+			var rangeHeader, ifRange string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rangeHeader, ifRange = r.Header.Get("Range"), r.Header.Get("If-Range")
+				tt.respond(w, r)
+			}))
+			defer server.Close()
+
+			destination := filepath.Join(t.TempDir(), "archive.tar.gz")
+			if err := os.WriteFile(destination, []byte(archiveContent[:offset]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(validatorPath(destination), []byte(etag), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			captureStdout(t, func() {
+				err = fetchArchiveRange(server.URL+"/archive.tar.gz", destination)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("fetchArchiveRange() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("fetchArchiveRange() error = %v", err)
+			}
+
+			if rangeHeader != fmt.Sprintf("bytes=%d-", offset) || ifRange != etag {
+				t.Errorf("request with Range %q and If-Range %q, want bytes=%d- and %s", rangeHeader, ifRange, offset, etag)
+			}
+			if tt.want == "" {
+				if _, err := os.Stat(destination); !os.IsNotExist(err) {
+					t.Errorf("partial file was kept: %v", err)
+				}
+			} else {
+				assertContent(t, destination, tt.want)
+			}
+			assertContent(t, validatorPath(destination), tt.wantValidator)
+		})
+	}
+}
+
+func TestDownloadArchiveVerifiesHash(t *testing.T) {
+	digest := sha512.Sum512([]byte(archiveContent))
+	hash := hex.EncodeToString(digest[:]) + "  archive.tar.gz\n"
+
+	tests := []struct {
+		name    string
+		archive string
+		wantErr string
+	}{
+		{name: "matching hash", archive: archiveContent},
+		{name: "corrupted archive", archive: "corrupted" + archiveContent[9:], wantErr: "archive verification failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetries(t)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/archive.tar.gz":
+					fmt.Fprint(w, tt.archive)
+				case "/archive.tar.gz.sha512":
+					fmt.Fprint(w, hash)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			destination := filepath.Join(dir, "archive.tar.gz")
+			var err error
+			captureStdout(t, func() {
+				err = downloadArchive(server.URL+"/archive.tar.gz", destination)
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("downloadArchive() error = %v", err)
+				}
+				assertContent(t, destination, archiveContent)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("downloadArchive() error = %v, want %q", err, tt.wantErr)
+			}
+			// The rejected archive is removed with its hash file
+			assertFiles(t, dir)
+		})
+	}
+}
+
+// assertContent checks the content of a file
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s has %d bytes, want %d", filepath.Base(path), len(data), len(want))
+	}
+}
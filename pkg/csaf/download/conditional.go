@@ -0,0 +1,75 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+)
+
+// conditionalResult is the outcome of a conditional request
+type conditionalResult struct {
+	// Data is the response body, empty if the resource was not modified or not found
+	Data        []byte
+	NotModified bool
+	NotFound    bool
+	// Validators are the ones to send with the next request for the resource
+	Validators cache.HTTPValidators
+}
+
+// fetchConditional performs a GET request that carries the validators of a previous
+// fetch as If-None-Match and If-Modified-Since headers. A 304 Not Modified response
+// is reported instead of an error, as is a 404 Not Found.
+func fetchConditional(url string, previous cache.HTTPValidators) (*conditionalResult, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	if previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+	if previous.LastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
+
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return &conditionalResult{NotModified: true, Validators: previous}, nil
+	case http.StatusNotFound:
+		return &conditionalResult{NotFound: true}, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("failed to fetch %s: HTTP %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+
+	return &conditionalResult{
+		Data: data,
+		Validators: cache.HTTPValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
+// recordValidators stores the validators of a fetched resource, dropping resources the
+// server does not return validators for
+func recordValidators(validators map[string]cache.HTTPValidators, url string, result *conditionalResult) {
+	if result == nil || result.NotFound || (result.Validators == cache.HTTPValidators{}) {
+		delete(validators, url)
+		return
+	}
+	validators[url] = result.Validators
+}
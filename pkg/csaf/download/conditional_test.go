@@ -0,0 +1,138 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+)
+
+// noRetries installs a default HTTP client that does not retry failed requests
+func noRetries(t *testing.T) {
+	t.Helper()
+	cfg := httpclient.DefaultConfig
+	cfg.Retry.MaxRetries = 0
+	client, err := httpclient.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := httpclient.Default()
+	httpclient.SetDefault(client)
+	t.Cleanup(func() { httpclient.SetDefault(previous) })
+}
+
+func TestFetchConditional(t *testing.T) {
+	noRetries(t)
+	const (
+		etag         = `"v2"`
+		lastModified = "Wed, 14 Oct 2026 10:00:00 GMT"
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/changes.csv":
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", lastModified)
+			fmt.Fprint(w, `"2024/a.json","2024-01-01T00:00:00Z"`)
+		case "/index.txt":
+			fmt.Fprint(w, "2024/a.json\n")
+		case "/broken.csv":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		path           string
+		previous       cache.HTTPValidators
+		want           conditionalResult
+		wantValidators bool
+		wantErr        bool
+	}{
+		{
+			name: "first fetch",
+			path: "/changes.csv",
+			want: conditionalResult{
+				Data:       []byte(`"2024/a.json","2024-01-01T00:00:00Z"`),
+				Validators: cache.HTTPValidators{ETag: etag, LastModified: lastModified},
+			},
+			wantValidators: true,
+		},
+		{
+			name:     "changed since the previous fetch",
+			path:     "/changes.csv",
+			previous: cache.HTTPValidators{ETag: `"v1"`, LastModified: "Tue, 13 Oct 2026 10:00:00 GMT"},
+			want: conditionalResult{
+				Data:       []byte(`"2024/a.json","2024-01-01T00:00:00Z"`),
+				Validators: cache.HTTPValidators{ETag: etag, LastModified: lastModified},
+			},
+			wantValidators: true,
+		},
+		{
+			name:     "not modified",
+			path:     "/changes.csv",
+			previous: cache.HTTPValidators{ETag: etag, LastModified: lastModified},
+			want: conditionalResult{
+				NotModified: true,
+				Validators:  cache.HTTPValidators{ETag: etag, LastModified: lastModified},
+			},
+			wantValidators: true,
+		},
+		{
+			name: "no validators returned",
+			path: "/index.txt",
+			want: conditionalResult{Data: []byte("2024/a.json\n")},
+		},
+		{
+			name:     "not found",
+			path:     "/missing.txt",
+			previous: cache.HTTPValidators{ETag: etag},
+			want:     conditionalResult{NotFound: true},
+		},
+		{
+			name:    "server error",
+			path:    "/broken.csv",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := server.URL + tt.path
+			got, err := fetchConditional(url, tt.previous)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("fetchConditional() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchConditional() error = %v", err)
+			}
+			if string(got.Data) != string(tt.want.Data) || got.NotModified != tt.want.NotModified ||
+				got.NotFound != tt.want.NotFound || got.Validators != tt.want.Validators {
+				t.Errorf("fetchConditional() = %+v, want %+v", got, tt.want)
+			}
+
+			// Validators stored by a previous sync are replaced or dropped
+			validators := map[string]cache.HTTPValidators{url: {ETag: `"stale"`}}
+			recordValidators(validators, url, got)
+			stored, ok := validators[url]
+			if ok != tt.wantValidators {
+				t.Fatalf("recordValidators() stored = %v, want %v", ok, tt.wantValidators)
+			}
+			if ok && stored != tt.want.Validators {
+				t.Errorf("recordValidators() stored %+v, want %+v", stored, tt.want.Validators)
+			}
+		})
+	}
+}
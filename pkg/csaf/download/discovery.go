@@ -0,0 +1,199 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Discovery methods, tried in the order of section 7.3.1 of the CSAF specification
+const (
+	DiscoveryWellKnown   = "well-known URL"
+	DiscoverySecurityTxt = "security.txt"
+	DiscoveryDNS         = "DNS path"
+)
+
+// DiscoveryAttempt is the outcome of one discovery method
+type DiscoveryAttempt struct {
+	Method string
+	// URL is the last URL the method tried
+	URL string
+	// Err is the reason the method failed, nil if it found the provider
+	Err error
+}
+
+// Discovery records how the provider-metadata.json of a domain was found
+type Discovery struct {
+	// ProviderURL is the URL the provider metadata was fetched from
+	ProviderURL string
+	Metadata    *ProviderMetadata
+	// Attempts lists the methods tried, the last one being the method that succeeded
+	Attempts []DiscoveryAttempt
+}
+
+// Method returns the discovery method that found the provider metadata
+func (d *Discovery) Method() string {
+	return d.Attempts[len(d.Attempts)-1].Method
+}
+
+// DiscoveryError is returned if none of the discovery methods found the provider metadata
+type DiscoveryError struct {
+	Domain   string
+	Attempts []DiscoveryAttempt
+}
+
+func (e *DiscoveryError) Error() string {
+	reasons := make([]string, len(e.Attempts))
+	for i, attempt := range e.Attempts {
+		reasons[i] = fmt.Sprintf("%s: %v", attempt.Method, attempt.Err)
+	}
+	return fmt.Sprintf("no CSAF provider found for %s (%s)", e.Domain, strings.Join(reasons, "; "))
+}
+
+// Discoverer finds the provider-metadata.json of domains. The zero value contacts the
+// domains over HTTPS as they are named.
+type Discoverer struct {
+	// Scheme is used for domains given without a scheme, https if empty
+	Scheme string
+	// Resolve returns the address contacted in place of a host, such as the host and port
+	// of a local test server. Attempts still report the URLs of the original host. If nil,
+	// hosts are contacted as they are.
+	Resolve func(host string) (string, error)
+}
+
+// DiscoverProvider finds the provider-metadata.json of a domain using the default Discoverer
+func DiscoverProvider(domain string) (*Discovery, error) {
+	return Discoverer{}.Discover(domain)
+}
+
+// Discover finds the provider-metadata.json of a domain. The well-known URL
+// https://<domain>/.well-known/csaf/provider-metadata.json is tried first, then the CSAF
+// fields of https://<domain>/.well-known/security.txt and finally the DNS path
+// https://csaf.data.security.<domain>. A domain given with a scheme, such as
+// http://localhost:8080, is contacted with that scheme.
+func (d Discoverer) Discover(domain string) (*Discovery, error) {
+	defaultScheme := d.Scheme
+	if defaultScheme == "" {
+		defaultScheme = "https"
+	}
+	scheme, host, err := splitDomain(domain, defaultScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery := &Discovery{}
+	try := func(method, providerURL string) bool {
+		fetchURL, err := d.resolve(providerURL)
+		var metadata *ProviderMetadata
+		if err == nil {
+			metadata, err = FromProviderURL(fetchURL)
+		}
+		discovery.Attempts = append(discovery.Attempts, DiscoveryAttempt{Method: method, URL: providerURL, Err: err})
+		if err != nil {
+			return false
+		}
+		discovery.ProviderURL = fetchURL
+		discovery.Metadata = metadata
+		return true
+	}
+
+	if try(DiscoveryWellKnown, scheme+"://"+host+"/.well-known/csaf/provider-metadata.json") {
+		return discovery, nil
+	}
+
+	securityTxtURL := scheme + "://" + host + "/.well-known/security.txt"
+	fetchURL, err := d.resolve(securityTxtURL)
+	var providerURLs []string
+	if err == nil {
+		providerURLs, err = securityTxtCSAFFields(fetchURL)
+	}
+	if err != nil {
+		discovery.Attempts = append(discovery.Attempts, DiscoveryAttempt{Method: DiscoverySecurityTxt, URL: securityTxtURL, Err: err})
+	}
+	for _, providerURL := range providerURLs {
+		if try(DiscoverySecurityTxt, providerURL) {
+			return discovery, nil
+		}
+	}
+
+	if try(DiscoveryDNS, scheme+"://csaf.data.security."+host) {
+		return discovery, nil
+	}
+
+	return nil, &DiscoveryError{Domain: domain, Attempts: discovery.Attempts}
+}
+
+// resolve returns the URL that is fetched in place of rawURL
+func (d Discoverer) resolve(rawURL string) (string, error) {
+	if d.Resolve == nil {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	address, err := d.Resolve(u.Host)
+	if err != nil {
+		return "", err
+	}
+	u.Host = address
+	return u.String(), nil
+}
+
+// splitDomain returns the scheme and host of a domain given with or without a scheme
+func splitDomain(domain, defaultScheme string) (string, string, error) {
+	domain = strings.TrimSpace(domain)
+	if !strings.Contains(domain, "://") {
+		domain = defaultScheme + "://" + domain
+	}
+	u, err := url.Parse(domain)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid domain %q: %w", domain, err)
+	}
+	if u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return "", "", fmt.Errorf("invalid domain %q: expected a domain name such as example.com", domain)
+	}
+	return u.Scheme, u.Host, nil
+}
+
+// securityTxtCSAFFields fetches a security.txt file and returns the URLs of its CSAF fields
+func securityTxtCSAFFields(securityTxtURL string) ([]string, error) {
+	data, notFound, err := fetchResource(securityTxtURL, true)
+	if err != nil {
+		return nil, err
+	}
+	if notFound {
+		return nil, fmt.Errorf("%s not found", securityTxtURL)
+	}
+
+	urls := parseSecurityTxt(data)
+	if len(urls) == 0 {
+		return nil, errors.New("security.txt has no CSAF field")
+	}
+	return urls, nil
+}
+
+// parseSecurityTxt returns the values of the CSAF fields of a security.txt file as defined
+// in RFC 9116. Field names are case-insensitive; comments and the lines added by an OpenPGP
+// cleartext signature are ignored.
+func parseSecurityTxt(data []byte) []string {
+	var urls []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "CSAF") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			urls = append(urls, value)
+		}
+	}
+	return urls
+}
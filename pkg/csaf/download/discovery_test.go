@@ -0,0 +1,191 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testProviderMetadata = `{"distributions": [{"directory_url": "https://provider.test/.well-known/csaf/white/"}]}`
+
+// discoveryHosts serves files for a set of host names. Each host gets its own server, and
+// the stub resolver of the returned Discoverer maps the host names to them; other hosts
+// do not resolve. Every request is logged as host and path.
+type discoveryHosts struct {
+	mu       sync.Mutex
+	requests []string
+	servers  map[string]*httptest.Server
+}
+
+func newDiscoveryHosts(t *testing.T, files map[string]map[string]string) *discoveryHosts {
+	t.Helper()
+	t.Setenv("CSAFX_CACHE_DIR", t.TempDir())
+
+	// Fail fast instead of retrying unreachable hosts
+	noRetries(t)
+
+	h := &discoveryHosts{servers: make(map[string]*httptest.Server)}
+	for host, paths := range files {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.mu.Lock()
+			h.requests = append(h.requests, host+r.URL.Path)
+			h.mu.Unlock()
+			content, ok := paths[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, content)
+		}))
+		t.Cleanup(server.Close)
+		h.servers[host] = server
+	}
+	return h
+}
+
+func (h *discoveryHosts) discoverer() Discoverer {
+	return Discoverer{
+		Scheme: "http",
+		Resolve: func(host string) (string, error) {
+			server, ok := h.servers[host]
+			if !ok {
+				return "", fmt.Errorf("lookup %s: no such host", host)
+			}
+			return strings.TrimPrefix(server.URL, "http://"), nil
+		},
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]map[string]string
+		// attempts are the methods tried and the URLs they report, the last one succeeding
+		attempts [][2]string
+		requests []string
+	}{
+		{
+			name: "well-known URL",
+			files: map[string]map[string]string{
+				"example.test": {"/.well-known/csaf/provider-metadata.json": testProviderMetadata},
+			},
+			attempts: [][2]string{
+				{DiscoveryWellKnown, "http://example.test/.well-known/csaf/provider-metadata.json"},
+			},
+			requests: []string{"example.test/.well-known/csaf/provider-metadata.json"},
+		},
+		{
+			name: "security.txt",
+			files: map[string]map[string]string{
+				"example.test": {"/.well-known/security.txt": "Contact: mailto:psirt@example.test\n" +
+					"CSAF: http://provider.test/missing/provider-metadata.json\n" +
+					"csaf: http://provider.test/csaf/provider-metadata.json\n"},
+				"provider.test": {"/csaf/provider-metadata.json": testProviderMetadata},
+			},
+			attempts: [][2]string{
+				{DiscoveryWellKnown, "http://example.test/.well-known/csaf/provider-metadata.json"},
+				{DiscoverySecurityTxt, "http://provider.test/missing/provider-metadata.json"},
+				{DiscoverySecurityTxt, "http://provider.test/csaf/provider-metadata.json"},
+			},
+			requests: []string{
+				"example.test/.well-known/csaf/provider-metadata.json",
+				"example.test/.well-known/security.txt",
+				"provider.test/missing/provider-metadata.json",
+				"provider.test/csaf/provider-metadata.json",
+			},
+		},
+		{
+			name: "DNS path",
+			files: map[string]map[string]string{
+				"example.test":                    {},
+				"csaf.data.security.example.test": {"/": testProviderMetadata},
+			},
+			attempts: [][2]string{
+				{DiscoveryWellKnown, "http://example.test/.well-known/csaf/provider-metadata.json"},
+				{DiscoverySecurityTxt, "http://example.test/.well-known/security.txt"},
+				{DiscoveryDNS, "http://csaf.data.security.example.test"},
+			},
+			requests: []string{
+				"example.test/.well-known/csaf/provider-metadata.json",
+				"example.test/.well-known/security.txt",
+				"csaf.data.security.example.test/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := newDiscoveryHosts(t, tt.files)
+			var discovery *Discovery
+			var err error
+			captureStdout(t, func() {
+				discovery, err = hosts.discoverer().Discover("example.test")
+			})
+			if err != nil {
+				t.Fatalf("Discover() error = %v", err)
+			}
+
+			if len(discovery.Attempts) != len(tt.attempts) {
+				t.Fatalf("Discover() attempts = %+v, want %d attempts", discovery.Attempts, len(tt.attempts))
+			}
+			for i, attempt := range discovery.Attempts {
+				if attempt.Method != tt.attempts[i][0] || attempt.URL != tt.attempts[i][1] {
+					t.Errorf("attempt %d = %s %s, want %s %s", i, attempt.Method, attempt.URL, tt.attempts[i][0], tt.attempts[i][1])
+				}
+				last := i == len(discovery.Attempts)-1
+				if last && attempt.Err != nil {
+					t.Errorf("successful attempt %d has error %v", i, attempt.Err)
+				}
+				if !last && attempt.Err == nil {
+					t.Errorf("failed attempt %d has no error", i)
+				}
+			}
+			if want := tt.attempts[len(tt.attempts)-1][0]; discovery.Method() != want {
+				t.Errorf("Method() = %s, want %s", discovery.Method(), want)
+			}
+			if len(discovery.Metadata.Distributions) != 1 {
+				t.Errorf("Metadata.Distributions = %+v, want one distribution", discovery.Metadata.Distributions)
+			}
+			if !slices.Equal(hosts.requests, tt.requests) {
+				t.Errorf("requests = %q, want %q", hosts.requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestDiscoverReportsEveryFailedAttempt(t *testing.T) {
+	hosts := newDiscoveryHosts(t, map[string]map[string]string{
+		"example.test": {"/.well-known/security.txt": "CSAF: http://provider.test/provider-metadata.json\n"},
+	})
+
+	var err error
+	captureStdout(t, func() {
+		_, err = hosts.discoverer().Discover("example.test")
+	})
+
+	var discoveryErr *DiscoveryError
+	if !errors.As(err, &discoveryErr) {
+		t.Fatalf("Discover() error = %v, want a DiscoveryError", err)
+	}
+	wantMethods := []string{DiscoveryWellKnown, DiscoverySecurityTxt, DiscoveryDNS}
+	if len(discoveryErr.Attempts) != len(wantMethods) {
+		t.Fatalf("DiscoveryError.Attempts = %+v, want %d attempts", discoveryErr.Attempts, len(wantMethods))
+	}
+	wantErrs := []string{"not found", "no such host", "no such host"}
+	for i, attempt := range discoveryErr.Attempts {
+		if attempt.Method != wantMethods[i] {
+			t.Errorf("attempt %d method = %s, want %s", i, attempt.Method, wantMethods[i])
+		}
+		if attempt.Err == nil || !strings.Contains(attempt.Err.Error(), wantErrs[i]) {
+			t.Errorf("attempt %d error = %v, want an error containing %q", i, attempt.Err, wantErrs[i])
+		}
+		if !strings.Contains(err.Error(), attempt.Method) {
+			t.Errorf("error %q does not mention %s", err, attempt.Method)
+		}
+	}
+}
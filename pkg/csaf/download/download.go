@@ -4,93 +4,27 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
-	"github.com/mholt/archiver/v3"
-
 	"github.com/mprpic/csafx/pkg/csaf/cache"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
 )
 
-func GetCSAFArchive(url, destination string) error {
-	fmt.Printf("Downloading CSAF archive from %s\n", url)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to archive file: %w", err)
-	}
-	defer resp.Body.Close()
-
-	out, err := os.Create(destination)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
-	}
-
-	fmt.Printf("Saved CSAF archive to %s\n", destination)
-	return nil
-}
-
-func ExtractCSAFArchive(archivePath, destination string) error {
-	fmt.Printf("Extracting CSAF archive to %s\n", destination)
-
-	zstReader, err := os.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("failed to open archive: %w", err)
-	}
-	defer zstReader.Close()
-
-	zr, err := zstd.NewReader(zstReader)
-	if err != nil {
-		return fmt.Errorf("failed to create zstd reader: %w", err)
-	}
-	defer zr.Close()
-
-	tarPath := filepath.Join(destination, "csaf_advisories.tar")
-	tarFile, err := os.Create(tarPath)
-	if err != nil {
-		return fmt.Errorf("failed to create tar file: %w", err)
-	}
-	defer tarFile.Close()
-
-	// Stream decompressed data directly to file instead of loading into memory
-	_, err = io.Copy(tarFile, zr)
-	if err != nil {
-		return fmt.Errorf("failed to decompress archive: %w", err)
-	}
-
-	// Close the tar file before extraction
-	tarFile.Close()
-
-	archive := archiver.NewTar()
-	err = archive.Unarchive(tarPath, destination)
-	if err != nil {
-		return fmt.Errorf("failed to extract tar archive: %w", err)
-	}
-
-	// Clean up the intermediate tar file
-	os.Remove(tarPath)
-
-	fmt.Printf("CSAF archive extracted to %s\n", destination)
-	return nil
-}
-
 // fetchResource performs an HTTP GET request and returns the response body as bytes
 func fetchResource(url string, allowNotFound bool) ([]byte, bool, error) {
-	resp, err := http.Get(url)
+	resp, err := httpclient.Get(url)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to fetch %s: %w", url, err)
 	}
@@ -143,96 +77,194 @@ func ParseChangesCSV(data []byte) (map[string]time.Time, error) {
 	return changes, nil
 }
 
-// IndividualFiles downloads a list of files from the base URL to the target directory
-func IndividualFiles(baseURL string, filePaths []string, targetDir string) error {
-	if len(filePaths) == 0 {
-		return nil
+// joinURL builds the URL of a file within a CSAF directory
+func joinURL(baseURL, filePath string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(filePath, "/")
+}
+
+// directoryFileURL returns a function that builds the URLs of files within a CSAF directory
+func directoryFileURL(baseURL string) func(string) string {
+	return func(filePath string) string {
+		return joinURL(baseURL, filePath)
 	}
+}
 
-	fmt.Printf("Downloading %d individual files...\n", len(filePaths))
+// downloadFile downloads a single file to the given local path
+func downloadFile(fileURL, localPath string) error {
+	resp, err := httpclient.Get(fileURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", fileURL, err)
+	}
+	defer resp.Body.Close()
 
-	for i, filePath := range filePaths {
-		if strings.TrimSpace(filePath) == "" {
-			continue // Skip empty file paths
-		}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: HTTP %d", fileURL, resp.StatusCode)
+	}
 
-		// Construct full URL for the file
-		fileURL := strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(filePath, "/")
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", localPath, err)
+	}
+	defer file.Close()
 
-		// Determine local file path
-		localPath := filepath.Join(targetDir, filePath)
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return fmt.Errorf("failed to save file %s: %w", localPath, err)
+	}
+	return nil
+}
 
-		// Create directory structure if needed
-		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", localPath, err)
-		}
+// IndividualFiles downloads a list of files from the base URL to the target directory,
+// verifies them against their published hashes and, if a signature verifier is given,
+// verifies their signatures. The TLP label of every document is recorded. Files are
+// downloaded in parallel; files that fail are returned as FileErrors without stopping
+// the others.
+func IndividualFiles(baseURL string, filePaths []string, targetDir string, opts Options,
+	hashes *HashVerifier, signatures *SignatureVerifier, labels *TLPRecorder) error {
+	filePaths = safePaths(nonEmptyPaths(filePaths))
+	if len(filePaths) == 0 {
+		return nil
+	}
 
-		// Download the file
-		resp, err := http.Get(fileURL)
-		if err != nil {
-			return fmt.Errorf("failed to download %s: %w", fileURL, err)
-		}
-		defer resp.Body.Close()
+	fmt.Printf("Downloading %d individual files...\n", len(filePaths))
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("failed to download %s: HTTP %d", fileURL, resp.StatusCode)
-		}
+	return forEachFile(directoryFileURL(baseURL), filePaths, opts, "Downloaded", 10, func(fileURL, filePath string) error {
+		return fetchDocument(fileURL, filePath, targetDir, nil, hashes, signatures, labels)
+	})
+}
 
-		// Create the local file
-		file, err := os.Create(localPath)
-		if err != nil {
-			return fmt.Errorf("failed to create file %s: %w", localPath, err)
-		}
-		defer file.Close()
+// fetchDocument downloads a single document into the data set in targetDir and verifies it.
+// Hash and signature files are fetched from sidecarURLs if given, such as the links of a
+// ROLIE feed entry, and from the document URL with the usual extensions otherwise.
+func fetchDocument(fileURL, filePath, targetDir string, sidecarURLs []string,
+	hashes *HashVerifier, signatures *SignatureVerifier, labels *TLPRecorder) error {
+	// Determine local file path
+	localPath := filepath.Join(targetDir, filepath.FromSlash(filePath))
 
-		// Copy content
-		_, err = io.Copy(file, resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to save file %s: %w", localPath, err)
-		}
+	// Create directory structure if needed
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", localPath, err)
+	}
 
-		if (i+1)%10 == 0 || i == len(filePaths)-1 {
-			fmt.Printf("Downloaded %d/%d files\n", i+1, len(filePaths))
-		}
+	if err := downloadFile(fileURL, localPath); err != nil {
+		return err
 	}
 
+	// Remove hash and signature files left over from a previous sync so the current ones are fetched
+	removeSidecars(localPath)
+	for _, sidecarURL := range sidecarURLs {
+		fetchSidecar(sidecarURL, localPath)
+	}
+
+	if err := hashes.VerifyFile(fileURL, filePath, localPath, targetDir); err != nil {
+		return err
+	}
+	if signatures != nil {
+		signatures.VerifyFile(fileURL, filePath, localPath)
+	}
+	labels.Record(filePath, localPath)
 	return nil
 }
 
-// PerformIncrementalUpdate downloads only changed files since the last sync
-func PerformIncrementalUpdate(directoryURL, targetDir string, lastSync time.Time) error {
+// PerformIncrementalUpdate downloads only changed files since the last sync, together with
+// the files that failed to download during the previous sync. changesData is the content
+// of the directory's changes.csv; it is empty if changes.csv was not modified. If indexData,
+// the content of index.txt, is given, the data set is reconciled with it: documents missing
+// locally are downloaded and documents no longer listed are removed, unless opts.KeepRemoved
+// is set.
+func PerformIncrementalUpdate(directoryURL, targetDir string, changesData, indexData []byte, lastSync time.Time,
+	retry []string, opts Options, hashes *HashVerifier, signatures *SignatureVerifier, labels *TLPRecorder) (*SyncReport, error) {
 	fmt.Printf("Performing incremental update (changes since %s)\n", lastSync.Format(time.RFC3339))
-
-	// Download changes.csv
-	changesURL := strings.TrimSuffix(directoryURL, "/") + "/changes.csv"
-	changesData, _, err := fetchResource(changesURL, false)
-	if err != nil {
-		return fmt.Errorf("failed to get changes.csv: %w", err)
-	}
+	report := &SyncReport{}
 
 	// Parse changes.csv
 	allChanges, err := ParseChangesCSV(changesData)
 	if err != nil {
-		return fmt.Errorf("failed to parse changes.csv: %w", err)
+		return report, fmt.Errorf("failed to parse changes.csv: %w", err)
 	}
 
 	// Filter to only files modified after lastSync
-	var changedFiles []string
+	var candidates []string
 	for filePath, timestamp := range allChanges {
 		if timestamp.After(lastSync) {
+			candidates = append(candidates, filePath)
+		}
+	}
+	candidates = append(candidates, retry...)
+
+	local, err := cache.ListDocuments(targetDir)
+	if err != nil {
+		return report, err
+	}
+	localSet := make(map[string]struct{}, len(local))
+	for _, filePath := range local {
+		localSet[filePath] = struct{}{}
+	}
+
+	if indexData != nil {
+		missing, removed, err := reconcileIndex(local, indexData)
+		if err != nil {
+			fmt.Printf("Warning: not reconciling the data set with index.txt: %v\n", err)
+		} else {
+			candidates = append(candidates, missing...)
+			report.Removed = removed
+			report.Reconciled = true
+		}
+	}
+
+	// Drop unsafe and duplicate paths
+	seen := make(map[string]struct{})
+	var changedFiles []string
+	for _, filePath := range safePaths(nonEmptyPaths(candidates)) {
+		if _, ok := seen[filePath]; !ok {
+			seen[filePath] = struct{}{}
 			changedFiles = append(changedFiles, filePath)
 		}
 	}
+	sort.Strings(changedFiles)
 
-	if len(changedFiles) == 0 {
+	if len(changedFiles) == 0 && len(report.Removed) == 0 {
 		fmt.Println("No files have changed since last sync")
-		return nil
+		return report, nil
+	}
+
+	var failed FileErrors
+	if len(changedFiles) > 0 {
+		fmt.Printf("Found %d files to update\n", len(changedFiles))
+
+		// Download the changed files
+		err = IndividualFiles(directoryURL, changedFiles, targetDir, opts, hashes, signatures, labels)
+		if err != nil && !errors.As(err, &failed) {
+			return report, err
+		}
+	}
+
+	for _, filePath := range changedFiles {
+		if _, ok := failed[filePath]; ok {
+			continue
+		}
+		if _, ok := localSet[filePath]; ok {
+			report.Updated = append(report.Updated, filePath)
+		} else {
+			report.Added = append(report.Added, filePath)
+		}
 	}
 
-	fmt.Printf("Found %d files to update\n", len(changedFiles))
+	if len(report.Removed) > 0 && !opts.KeepRemoved {
+		fmt.Printf("Removing %d documents that are no longer listed in index.txt\n", len(report.Removed))
+		removeDocuments(targetDir, report.Removed)
+		for _, filePath := range report.Removed {
+			hashes.forget(filePath)
+			labels.forget(filePath)
+			if signatures != nil {
+				signatures.forget(filePath)
+			}
+		}
+	}
 
-	// Download the changed files
-	return IndividualFiles(directoryURL, changedFiles, targetDir)
+	if len(failed) > 0 {
+		return report, failed
+	}
+	return report, nil
 }
 
 // urlToDirectoryName converts a URL to a directory name that is used as a
@@ -256,9 +288,33 @@ func urlToDirectoryName(rawURL string) string {
 	return sanitized
 }
 
+// Options configures the download of a CSAF data set
+type Options struct {
+	// ProviderURL is the provider-metadata.json the directory belongs to. Its public OpenPGP
+	// keys are used to verify document signatures. If empty, the provider recorded by a
+	// previous sync is used; without a provider, signatures are not verified.
+	ProviderURL string
+	// Concurrency is the number of files downloaded in parallel, DefaultConcurrency if zero
+	Concurrency int
+	// MaxPerHost limits the number of parallel requests to a single host, unlimited if zero
+	MaxPerHost int
+	// KeepRemoved keeps documents that are no longer listed in index.txt and marks them in
+	// the sync metadata instead of removing them
+	KeepRemoved bool
+}
+
+// newSignatureVerifier creates a verifier from the OpenPGP keys of a provider
+func newSignatureVerifier(providerURL string) (*SignatureVerifier, error) {
+	providerMetadata, err := FromProviderURL(providerURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewSignatureVerifier(providerMetadata.PublicOpenPGPKeys)
+}
+
 // FromDirectoryURL downloads a CSAF data set from a specific directory URL to
 // the cache with support for incremental updates using changes.csv
-func FromDirectoryURL(directoryURL string) (string, error) {
+func FromDirectoryURL(directoryURL string, opts Options) (string, error) {
 	cachePath, err := cache.EnsureCachePath()
 	if err != nil {
 		return "", fmt.Errorf("failed to ensure cache path: %w", err)
@@ -267,8 +323,8 @@ func FromDirectoryURL(directoryURL string) (string, error) {
 	dirName := urlToDirectoryName(directoryURL)
 	targetPath := filepath.Join(cachePath, dirName)
 
-	if err := os.MkdirAll(targetPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create target directory: %w", err)
+	if err := cache.CleanStagingDirs(dirName); err != nil {
+		return "", err
 	}
 
 	// Check if valid cache exists for incremental update
@@ -277,25 +333,138 @@ func FromDirectoryURL(directoryURL string) (string, error) {
 		return "", fmt.Errorf("failed to check cache validity: %w", err)
 	}
 
+	providerURL := opts.ProviderURL
+	if providerURL == "" && metadata != nil {
+		providerURL = metadata.ProviderURL
+	}
+
+	validators := make(map[string]cache.HTTPValidators)
+	changesURL := strings.TrimSuffix(directoryURL, "/") + "/changes.csv"
+	indexURL := strings.TrimSuffix(directoryURL, "/") + "/index.txt"
+	var changes, index *conditionalResult
+	var indexErr error
+	if metadata != nil {
+		for url, v := range metadata.Validators {
+			validators[url] = v
+		}
+
+		// Ask whether changes.csv changed since the last sync before anything else, so
+		// that syncing a data set without changes takes a single request
+		changes, err = fetchConditional(changesURL, validators[changesURL])
+		if err != nil && isValid {
+			return "", fmt.Errorf("incremental update failed: failed to get changes.csv: %w", err)
+		}
+		if changes != nil && changes.NotFound && isValid {
+			return "", fmt.Errorf("incremental update failed: %s not found", changesURL)
+		}
+
+		if changes != nil && changes.NotModified {
+			// Nothing changed, so a stale data set only needs the failed downloads retried
+			isValid = true
+			if len(metadata.FailedDownloads) == 0 {
+				// Documents can be withdrawn from index.txt without an entry in changes.csv
+				index, indexErr = fetchConditional(indexURL, validators[indexURL])
+				if indexErr == nil && index.NotModified {
+					fmt.Printf("No changes since last sync (%s), data set is up to date\n",
+						metadata.LastSync.Format(time.RFC3339))
+					metadata.LastSync = time.Now()
+					if err := cache.SaveSyncMetadata(targetPath, metadata); err != nil {
+						return "", fmt.Errorf("failed to save sync metadata: %w", err)
+					}
+					return targetPath, nil
+				}
+			}
+		}
+	}
+
+	hashes := NewHashVerifier()
+	labels := NewTLPRecorder()
+	var signatures *SignatureVerifier
+	if providerURL != "" {
+		fmt.Printf("Fetching OpenPGP keys of provider %s\n", providerURL)
+		signatures, err = newSignatureVerifier(providerURL)
+		if err != nil {
+			fmt.Printf("Warning: signatures will not be verified: %v\n", err)
+		}
+	} else {
+		fmt.Println("Warning: no provider metadata known for this directory, signatures will not be verified")
+	}
+
 	if isValid && metadata != nil {
 		// Perform incremental update
 		fmt.Printf("Valid cache found (last sync: %s), performing incremental update\n",
 			metadata.LastSync.Format(time.RFC3339))
 
-		err := PerformIncrementalUpdate(directoryURL, targetPath, metadata.LastSync)
-		if err != nil {
+		hashes.MergeQuarantined(metadata.Quarantined)
+		labels.MergeLabels(metadata.TLPLabels)
+		if signatures != nil {
+			signatures.MergeFailures(metadata.SignatureFailures)
+		}
+
+		// index.txt only needs to be compared with the data set if it changed
+		if index == nil && indexErr == nil {
+			index, indexErr = fetchConditional(indexURL, validators[indexURL])
+		}
+		var indexData []byte
+		switch {
+		case indexErr != nil:
+			fmt.Printf("Warning: not reconciling the data set with index.txt: %v\n", indexErr)
+		case index.NotFound:
+			fmt.Printf("Warning: not reconciling the data set with index.txt: %s not found\n", indexURL)
+		case !index.NotModified:
+			indexData = index.Data
+		}
+
+		report, err := PerformIncrementalUpdate(directoryURL, targetPath, changes.Data, indexData, metadata.LastSync,
+			metadata.FailedDownloads, opts, hashes, signatures, labels)
+		var failed FileErrors
+		if err != nil && !errors.As(err, &failed) {
 			return "", fmt.Errorf("incremental update failed: %w", err)
 		}
+		recordValidators(validators, changesURL, changes)
+		if index != nil && (index.NotModified || report.Reconciled) {
+			recordValidators(validators, indexURL, index)
+		}
+
+		// Documents withdrawn upstream stay marked until index.txt is reconciled again
+		removed := metadata.Removed
+		if report.Reconciled {
+			removed = nil
+			if opts.KeepRemoved {
+				removed = markRemoved(metadata.Removed, report.Removed)
+			}
+		}
 
 		// Update metadata with current sync time
 		newMetadata := &cache.SyncMetadata{
-			LastSync:  time.Now(),
-			SourceURL: directoryURL,
+			LastSync:        time.Now(),
+			SourceURL:       directoryURL,
+			ProviderURL:     providerURL,
+			ArchiveURL:      metadata.ArchiveURL,
+			Quarantined:     hashes.Quarantined(),
+			FailedDownloads: failed.Paths(),
+			Partial:         metadata.Partial && len(failed) > 0,
+			TLPLabels:       labels.Labels(),
+			Validators:      validators,
+			Removed:         removed,
+		}
+		report.Print(opts.KeepRemoved)
+		hashes.PrintSummary()
+		labels.PrintSummary()
+		if signatures != nil {
+			newMetadata.SignatureFailures = signatures.Failures()
+			signatures.PrintSummary()
 		}
 		if err := cache.SaveSyncMetadata(targetPath, newMetadata); err != nil {
 			return "", fmt.Errorf("failed to save sync metadata: %w", err)
 		}
 
+		if len(failed) > 0 {
+			fmt.Printf("Incremental update completed, %d files failed and will be retried on the next sync:\n", len(failed))
+			failed.Print()
+			return targetPath, fmt.Errorf("incremental update incomplete: %w", failed)
+		}
+
 		fmt.Println("Incremental update completed successfully")
 		return targetPath, nil
 	}
@@ -308,52 +477,80 @@ func FromDirectoryURL(directoryURL string) (string, error) {
 		fmt.Println("No cache found, performing full download")
 	}
 
-	// Clear cache directory for fresh download
-	if err := os.RemoveAll(targetPath); err != nil {
-		return "", fmt.Errorf("failed to clear cache directory: %w", err)
-	}
-
-	// Recreate the target directory
-	if err := os.MkdirAll(targetPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to recreate target directory: %w", err)
+	// Download into a staging directory that replaces the data set only once the download
+	// is complete, so that a failed download keeps the previous data set usable
+	stagingPath, err := cache.CreateStagingDir(dirName)
+	if err != nil {
+		return "", err
 	}
+	defer os.RemoveAll(stagingPath)
 
-	// Check if an archive is available
+	// Check if an archive is available. If archive_latest.txt did not change, it still
+	// names the archive recorded by the previous sync.
 	archiveLatestURL := strings.TrimSuffix(directoryURL, "/") + "/archive_latest.txt"
-	data, notFound, err := fetchResource(archiveLatestURL, true)
+	var previousArchive cache.HTTPValidators
+	if metadata != nil && metadata.ArchiveURL != "" {
+		previousArchive = validators[archiveLatestURL]
+	}
+	archiveLatest, err := fetchConditional(archiveLatestURL, previousArchive)
 	var archiveURL string
-	if notFound {
+	switch {
+	case err != nil:
+		return "", err
+	case archiveLatest.NotFound:
 		fmt.Printf("Warning: no archive found at %s\n", archiveLatestURL)
-	} else if err != nil {
-		return "", fmt.Errorf("failed to fetch %s: %w", archiveLatestURL, err)
-	} else if !notFound {
-		archiveURL = strings.TrimSuffix(directoryURL, "/") + "/" + strings.TrimSpace(string(data))
+	case archiveLatest.NotModified:
+		archiveURL = metadata.ArchiveURL
+	default:
+		archiveURL = strings.TrimSuffix(directoryURL, "/") + "/" + strings.TrimSpace(string(archiveLatest.Data))
+	}
+	recordValidators(validators, archiveLatestURL, archiveLatest)
+
+	// Partial downloads of archives that were replaced since can never be resumed
+	var archiveName string
+	if archiveURL != "" {
+		archiveName = dirName + "." + path.Base(archiveURL)
+	}
+	if err := cache.CleanPartialArchives(dirName, archiveName); err != nil {
+		return "", err
 	}
 
+	var failed FileErrors
 	if archiveURL != "" {
-		archivePath := filepath.Join(targetPath, "archive.tar.zst")
+		// The archive is kept next to the data set rather than in it, so that a partial
+		// download survives the data set being cleared and can be resumed
+		archivePath := filepath.Join(cachePath, archiveName)
 
-		err := GetCSAFArchive(archiveURL, archivePath)
+		err := downloadArchive(archiveURL, archivePath)
 		if err != nil {
 			return "", fmt.Errorf("failed to download archive: %w", err)
 		}
 
-		err = ExtractCSAFArchive(archivePath, targetPath)
+		err = ExtractCSAFArchive(archivePath, stagingPath)
 		if err != nil {
 			return "", fmt.Errorf("failed to extract archive: %w", err)
 		}
 
-		os.Remove(archivePath)
+		removeArchive(archivePath)
+
+		err = verifyDocuments(directoryURL, stagingPath, opts, hashes, signatures, labels)
+		if err != nil && !errors.As(err, &failed) {
+			return "", fmt.Errorf("failed to verify documents: %w", err)
+		}
 	} else {
 		// No archive available, download individual files from index.txt
 		fmt.Println("No archive available, downloading individual files from index.txt")
 		indexURL := strings.TrimSuffix(directoryURL, "/") + "/index.txt"
-		data, _, err := fetchResource(indexURL, false)
+		index, err := fetchConditional(indexURL, cache.HTTPValidators{})
+		if err == nil && index.NotFound {
+			err = fmt.Errorf("%s not found", indexURL)
+		}
 		if err != nil {
 			return "", fmt.Errorf("failed to fetch index.txt: %w", err)
 		}
+		recordValidators(validators, indexURL, index)
 
-		lines := strings.Split(string(data), "\n")
+		lines := strings.Split(string(index.Data), "\n")
 		var files []string
 		for _, line := range lines {
 			line = strings.TrimSpace(line)
@@ -366,25 +563,75 @@ func FromDirectoryURL(directoryURL string) (string, error) {
 			return "", fmt.Errorf("no files found in index.txt")
 		}
 
-		err = IndividualFiles(directoryURL, files, targetPath)
-		if err != nil {
+		err = IndividualFiles(directoryURL, files, stagingPath, opts, hashes, signatures, labels)
+		if err != nil && !errors.As(err, &failed) {
 			return "", fmt.Errorf("failed to download individual files: %w", err)
 		}
 	}
 
+	// A data set is only replaced by a complete download. Without a previous data set, an
+	// incomplete download is committed but marked as partial.
+	if len(failed) > 0 && metadata != nil {
+		return targetPath, keepPreviousDataSet(targetPath, failed)
+	}
+
+	// changes.csv was fetched before the download, so its validators are safe to keep
+	if changes != nil {
+		recordValidators(validators, changesURL, changes)
+	}
+
 	// Save metadata for successful full download
 	newMetadata := &cache.SyncMetadata{
-		LastSync:  time.Now(),
-		SourceURL: directoryURL,
-	}
-	if err := cache.SaveSyncMetadata(targetPath, newMetadata); err != nil {
+		LastSync:        time.Now(),
+		SourceURL:       directoryURL,
+		ProviderURL:     providerURL,
+		ArchiveURL:      archiveURL,
+		Quarantined:     hashes.Quarantined(),
+		FailedDownloads: failed.Paths(),
+		Partial:         len(failed) > 0,
+		TLPLabels:       labels.Labels(),
+		Validators:      validators,
+	}
+	hashes.PrintSummary()
+	labels.PrintSummary()
+	if signatures != nil {
+		newMetadata.SignatureFailures = signatures.Failures()
+		signatures.PrintSummary()
+	}
+	if err := cache.SaveSyncMetadata(stagingPath, newMetadata); err != nil {
 		return "", fmt.Errorf("failed to save sync metadata: %w", err)
 	}
 
+	if err := cache.CommitStagingDir(stagingPath, targetPath); err != nil {
+		return "", err
+	}
+
+	if len(failed) > 0 {
+		return targetPath, partialDataSet(targetPath, failed)
+	}
+
 	fmt.Println("Full download completed successfully")
 	return targetPath, nil
 }
 
+// keepPreviousDataSet reports a full download that failed for some files. The previous
+// data set is left untouched, including its sync metadata, so the next sync downloads it
+// in full again.
+func keepPreviousDataSet(dataSetPath string, failed FileErrors) error {
+	fmt.Printf("Full download failed for %d files, keeping the previous data set in %s:\n", len(failed), dataSetPath)
+	failed.Print()
+	return fmt.Errorf("full download incomplete, previous data set kept: %w", failed)
+}
+
+// partialDataSet reports a first download that failed for some files. The data set was
+// committed and marked as partial, and the failed files are retried on the next sync.
+func partialDataSet(dataSetPath string, failed FileErrors) error {
+	fmt.Printf("Full download failed for %d files, data set in %s is partial and they will be retried on the next sync:\n",
+		len(failed), dataSetPath)
+	failed.Print()
+	return fmt.Errorf("full download incomplete, partial data set kept: %w", failed)
+}
+
 // ProviderMetadata file as defined in the CSAF specification:
 // https://docs.oasis-open.org/csaf/csaf/v2.0/os/schemas/provider_json_schema.json
 type ProviderMetadata struct {
@@ -409,47 +656,97 @@ type Rolie struct {
 }
 
 type RolieFeed struct {
-	URL string `json:"url"`
+	Summary  string `json:"summary,omitempty"`
+	TLPLabel string `json:"tlp_label"`
+	URL      string `json:"url"`
 }
 
-// GetDirectoryURLs returns all directory URLs for this distribution.
-// If DirectoryURL is set, it returns that in a slice. Otherwise, it derives directory URLs
-// from all Rolie feed URLs by replacing the filename with the directory path.
-func (d *Distribution) GetDirectoryURLs() ([]string, error) {
+// Source is a location a CSAF data set is downloaded from: a CSAF directory with an
+// index.txt file, or a ROLIE feed
+type Source struct {
+	URL  string
+	Feed bool
+	// TLPLabel is the TLP label the provider metadata declares for a feed, or the label a
+	// directory is named after
+	TLPLabel string
+}
+
+// String describes the source for selection lists
+func (s Source) String() string {
+	if !s.Feed {
+		if s.TLPLabel != "" {
+			return fmt.Sprintf("%s (TLP:%s)", s.URL, s.TLPLabel)
+		}
+		return s.URL
+	}
+	if s.TLPLabel != "" {
+		return fmt.Sprintf("%s (ROLIE feed, TLP:%s)", s.URL, s.TLPLabel)
+	}
+	return s.URL + " (ROLIE feed)"
+}
+
+// GetSources returns the sources of this distribution. If DirectoryURL is set, the
+// directory is the only source; otherwise every ROLIE feed is a source of its own.
+func (d *Distribution) GetSources() ([]Source, error) {
 	if d.DirectoryURL != "" {
-		return []string{d.DirectoryURL}, nil
+		return []Source{{URL: d.DirectoryURL, TLPLabel: directoryTLPLabel(d.DirectoryURL)}}, nil
 	}
 
 	if d.Rolie != nil && len(d.Rolie.Feeds) > 0 {
-		uniqueURLs := make(map[string]struct{})
+		var sources []Source
 		for _, feed := range d.Rolie.Feeds {
 			if feed.URL == "" {
 				continue
 			}
-
-			// Extract the directory part by removing the filename
-			lastSlash := strings.LastIndex(feed.URL, "/")
-			if lastSlash == -1 {
-				continue
-			}
-
-			dirURL := feed.URL[:lastSlash+1]
-			uniqueURLs[dirURL] = struct{}{}
+			sources = append(sources, Source{URL: feed.URL, Feed: true, TLPLabel: strings.ToUpper(feed.TLPLabel)})
 		}
 
-		if len(uniqueURLs) == 0 {
+		if len(sources) == 0 {
 			return nil, fmt.Errorf("no valid rolie feed URLs found")
 		}
+		return sources, nil
+	}
 
-		var dirURLs []string
-		for url := range uniqueURLs {
-			dirURLs = append(dirURLs, url)
-		}
+	return nil, fmt.Errorf("no directory URL or rolie feeds found in distribution")
+}
+
+// directoryTLPLabel returns the TLP label of a CSAF directory named after its label, such as
+// https://example.com/.well-known/csaf/white/, or an empty string
+func directoryTLPLabel(directoryURL string) string {
+	u, err := url.Parse(directoryURL)
+	if err != nil {
+		return ""
+	}
+	switch label := strings.ToUpper(path.Base(u.Path)); label {
+	case "WHITE", "GREEN", "AMBER", "RED":
+		return label
+	}
+	return ""
+}
+
+// DataSetName returns the name of the cached data set downloaded from a directory or feed URL
+func DataSetName(sourceURL string) string {
+	return urlToDirectoryName(sourceURL)
+}
 
-		return dirURLs, nil
+// FromSource downloads a CSAF data set from a directory or a ROLIE feed
+func FromSource(source Source, opts Options) (string, error) {
+	if source.Feed {
+		return FromRolieFeed(source.URL, opts)
 	}
+	return FromDirectoryURL(source.URL, opts)
+}
 
-	return nil, fmt.Errorf("no directory URL or rolie feeds found in distribution")
+// Sync updates the cached data set downloaded from sourceURL, which is a CSAF directory or
+// a ROLIE feed as recorded in the metadata of the data set
+func Sync(sourceURL string, opts Options) (string, error) {
+	cachePath := cache.DetermineCachePath()
+	metadata, err := cache.LoadSyncMetadata(filepath.Join(cachePath, urlToDirectoryName(sourceURL)))
+	if err != nil {
+		return "", fmt.Errorf("failed to load sync metadata: %w", err)
+	}
+	feed := metadata != nil && metadata.SourceType == cache.SourceROLIE
+	return FromSource(Source{URL: sourceURL, Feed: feed}, opts)
 }
 
 type Publisher struct {
@@ -465,12 +762,29 @@ type OpenPGPKey struct {
 	URL         string `json:"url"`
 }
 
-// FromProviderURL downloads CSAF files from a provider metadata URL
+// FromProviderURL fetches and parses a provider metadata URL. The metadata is kept in the
+// cache, so that it is only downloaded again if the provider changed it.
 func FromProviderURL(providerURL string) (*ProviderMetadata, error) {
-	data, _, err := fetchResource(providerURL, false)
+	name := urlToDirectoryName(providerURL)
+	cached, previous, err := cache.LoadProviderMetadata(name)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if cached == nil {
+		previous = cache.HTTPValidators{}
+	}
+
+	result, err := fetchConditional(providerURL, previous)
+	if err == nil && result.NotFound {
+		err = fmt.Errorf("%s not found", providerURL)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch provider providerMetadata: %w", err)
 	}
+	data := result.Data
+	if result.NotModified {
+		data = cached
+	}
 
 	var providerMetadata ProviderMetadata
 	if err := json.Unmarshal(data, &providerMetadata); err != nil {
@@ -481,16 +795,27 @@ func FromProviderURL(providerURL string) (*ProviderMetadata, error) {
 		return nil, fmt.Errorf("no distributions found in provider providerMetadata")
 	}
 
+	if !result.NotModified {
+		if err := cache.SaveProviderMetadata(name, data, result.Validators); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
 	return &providerMetadata, nil
 }
 
+// DefaultAggregatorURL is the aggregator providers are selected from if none is configured
+const DefaultAggregatorURL = "https://wid.cert-bund.de/.well-known/csaf-aggregator/aggregator.json"
+
 // Aggregator file as defined in the CSAF specification:
 // https://docs.oasis-open.org/csaf/csaf/v2.0/os/schemas/aggregator_json_schema.json
 type Aggregator struct {
-	Aggregator        AggregatorInfo `json:"aggregator"`
-	AggregatorVersion string         `json:"aggregator_version"`
-	CanonicalURL      string         `json:"canonical_url"`
-	CSAFProviders     []CSAFProvider `json:"csaf_providers"`
+	Aggregator        AggregatorInfo  `json:"aggregator"`
+	AggregatorVersion string          `json:"aggregator_version"`
+	CanonicalURL      string          `json:"canonical_url"`
+	CSAFProviders     []CSAFProvider  `json:"csaf_providers"`
+	CSAFPublishers    []CSAFPublisher `json:"csaf_publishers,omitempty"`
+	LastUpdated       string          `json:"last_updated"`
 }
 
 type AggregatorInfo struct {
@@ -503,6 +828,14 @@ type AggregatorInfo struct {
 
 type CSAFProvider struct {
 	Metadata ProviderInfo `json:"metadata"`
+	// Mirrors are the URLs of the provider-metadata.json files of mirrors of the provider
+	Mirrors []string `json:"mirrors,omitempty"`
+}
+
+type CSAFPublisher struct {
+	Metadata       ProviderInfo `json:"metadata"`
+	Mirrors        []string     `json:"mirrors,omitempty"`
+	UpdateInterval string       `json:"update_interval"`
 }
 
 type ProviderInfo struct {
@@ -512,9 +845,8 @@ type ProviderInfo struct {
 	URL         string    `json:"url"`
 }
 
-// GetAvailableProviders fetches the list of available CSAF providers from the BSI aggregator
-func GetAvailableProviders() (*Aggregator, error) {
-	aggregatorURL := "https://wid.cert-bund.de/.well-known/csaf-aggregator/aggregator.json"
+// FetchAggregator fetches and parses an aggregator.json file
+func FetchAggregator(aggregatorURL string) (*Aggregator, error) {
 	data, _, err := fetchResource(aggregatorURL, false)
 	if err != nil {
 		return nil, err
@@ -527,3 +859,78 @@ func GetAvailableProviders() (*Aggregator, error) {
 
 	return &aggregator, nil
 }
+
+// AvailableProvider is a provider or publisher listed by one or more aggregators
+type AvailableProvider struct {
+	Metadata ProviderInfo
+	// Mirrors are the provider-metadata.json URLs of mirrors, from all aggregators
+	Mirrors []string
+	// Publisher is set for entries listed under csaf_publishers
+	Publisher bool
+}
+
+// GetAvailableProviders fetches the providers and publishers listed by the given aggregators.
+// Entries for the same provider-metadata.json are merged, keeping the mirrors of all of them.
+// An aggregator that cannot be fetched is skipped with a warning, unless all of them fail.
+func GetAvailableProviders(aggregatorURLs []string) ([]AvailableProvider, error) {
+	var providers []AvailableProvider
+	index := make(map[string]int)
+	add := func(metadata ProviderInfo, mirrors []string, publisher bool) {
+		if metadata.URL == "" {
+			return
+		}
+		i, ok := index[metadata.URL]
+		if !ok {
+			i = len(providers)
+			index[metadata.URL] = i
+			providers = append(providers, AvailableProvider{Metadata: metadata, Publisher: publisher})
+		}
+		for _, mirror := range mirrors {
+			if mirror != "" && mirror != metadata.URL && !slices.Contains(providers[i].Mirrors, mirror) {
+				providers[i].Mirrors = append(providers[i].Mirrors, mirror)
+			}
+		}
+	}
+
+	var errs []error
+	for _, aggregatorURL := range aggregatorURLs {
+		aggregator, err := FetchAggregator(aggregatorURL)
+		if err != nil {
+			fmt.Printf("Warning: skipping aggregator %s: %v\n", aggregatorURL, err)
+			errs = append(errs, err)
+			continue
+		}
+		for _, provider := range aggregator.CSAFProviders {
+			add(provider.Metadata, provider.Mirrors, false)
+		}
+		for _, publisher := range aggregator.CSAFPublishers {
+			add(publisher.Metadata, publisher.Mirrors, true)
+		}
+	}
+
+	if len(errs) == len(aggregatorURLs) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return providers, nil
+}
+
+// FetchMetadata fetches the provider metadata of an aggregated provider, falling back to its
+// mirrors in turn if the provider itself cannot be reached. It returns the URL the metadata
+// was fetched from.
+func (p AvailableProvider) FetchMetadata() (string, *ProviderMetadata, error) {
+	metadata, err := FromProviderURL(p.Metadata.URL)
+	if err == nil {
+		return p.Metadata.URL, metadata, nil
+	}
+
+	for _, mirror := range p.Mirrors {
+		fmt.Printf("Warning: %v, trying mirror %s\n", err, mirror)
+		var mirrorErr error
+		metadata, mirrorErr = FromProviderURL(mirror)
+		if mirrorErr == nil {
+			return mirror, metadata, nil
+		}
+		err = mirrorErr
+	}
+	return "", nil, err
+}
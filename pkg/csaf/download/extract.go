@@ -0,0 +1,263 @@
+package download
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// archiveFormat is a supported archive format, recognized by the magic bytes at its start
+type archiveFormat struct {
+	name  string
+	magic string
+}
+
+var (
+	formatZstd = archiveFormat{"tar.zst", "\x28\xb5\x2f\xfd"}
+	formatGzip = archiveFormat{"tar.gz", "\x1f\x8b"}
+	formatXz   = archiveFormat{"tar.xz", "\xfd7zXZ\x00"}
+	formatZip  = archiveFormat{"zip", "PK\x03\x04"}
+
+	archiveFormats = []archiveFormat{formatZstd, formatGzip, formatXz, formatZip}
+)
+
+// detectArchiveFormat determines the format of an archive from its first bytes, so that
+// archives are extracted correctly regardless of their file name
+func detectArchiveFormat(archivePath string) (archiveFormat, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return archiveFormat{}, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, 8)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return archiveFormat{}, fmt.Errorf("failed to read archive: %w", err)
+	}
+	for _, format := range archiveFormats {
+		if strings.HasPrefix(string(header[:n]), format.magic) {
+			return format, nil
+		}
+	}
+	return archiveFormat{}, fmt.Errorf("unsupported archive format, expected tar.zst, tar.gz, tar.xz or zip")
+}
+
+// ExtractCSAFArchive extracts a tar.zst, tar.gz, tar.xz or zip archive to destination.
+// Compressed tar archives are extracted in a single pass from the decompressed stream,
+// without writing the tar file to disk.
+func ExtractCSAFArchive(archivePath, destination string) error {
+	format, err := detectArchiveFormat(archivePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Extracting %s archive to %s\n", format.name, destination)
+
+	x := &extraction{destination: destination}
+	if format == formatZip {
+		err = x.zip(archivePath)
+	} else {
+		err = x.compressedTar(archivePath, format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if x.rejected > 0 {
+		fmt.Printf("CSAF archive extracted to %s (%d files, %d unsafe entries rejected)\n", destination, x.extracted, x.rejected)
+	} else {
+		fmt.Printf("CSAF archive extracted to %s (%d files)\n", destination, x.extracted)
+	}
+	return nil
+}
+
+// extraction writes the entries of an archive below destination. Only directories and
+// regular files with safe paths are extracted; links and entries with unsafe paths are
+// rejected, so a malicious archive cannot write outside of the data set.
+type extraction struct {
+	destination string
+	extracted   int
+	rejected    int
+}
+
+// reject logs an archive entry that is not extracted
+func (x *extraction) reject(name, reason string) {
+	fmt.Printf("Warning: rejected archive entry %q: %s\n", name, reason)
+	x.rejected++
+}
+
+// path returns the local path of an archive entry, or false if the entry was rejected
+func (x *extraction) path(name string) (string, bool) {
+	filePath, err := safePath(name)
+	if err != nil {
+		x.reject(name, err.Error())
+		return "", false
+	}
+	if x.linkInPath(filePath) {
+		x.reject(name, "path passes through a symbolic link")
+		return "", false
+	}
+	return filepath.Join(x.destination, filepath.FromSlash(filePath)), true
+}
+
+// linkInPath reports whether a path below destination, or any of its parent directories,
+// is a symbolic link that is already in place. Writing through it could leave destination.
+func (x *extraction) linkInPath(filePath string) bool {
+	current := x.destination
+	for _, segment := range strings.Split(filePath, "/") {
+		current = filepath.Join(current, segment)
+		info, err := os.Lstat(current)
+		if err != nil {
+			return false
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dir creates a directory entry. The entry for the archive root itself is skipped.
+func (x *extraction) dir(name string) error {
+	if path.Clean(name) == "." {
+		return nil
+	}
+	target, ok := x.path(name)
+	if !ok {
+		return nil
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return nil
+}
+
+// file writes a regular file entry
+func (x *extraction) file(name string, content io.Reader) error {
+	target, ok := x.path(name)
+	if !ok {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+
+	out, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", target, err)
+	}
+	if _, err := io.Copy(out, content); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	x.extracted++
+	return out.Close()
+}
+
+// compressedTar streams a compressed tar archive through the matching decompressor into archive/tar
+func (x *extraction) compressedTar(archivePath string, format archiveFormat) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+	input := bufio.NewReader(file)
+
+	var stream io.Reader
+	switch format {
+	case formatZstd:
+		zr, err := zstd.NewReader(input)
+		if err != nil {
+			return fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		defer zr.Close()
+		stream = zr
+	case formatGzip:
+		gr, err := gzip.NewReader(input)
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		stream = gr
+	case formatXz:
+		xr, err := xz.NewReader(input)
+		if err != nil {
+			return fmt.Errorf("failed to create xz reader: %w", err)
+		}
+		stream = xr
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(header.Name)
+		case tar.TypeReg:
+			err = x.file(header.Name, tr)
+		case tar.TypeSymlink:
+			x.reject(header.Name, "symbolic link to "+header.Linkname)
+		case tar.TypeLink:
+			x.reject(header.Name, "hard link to "+header.Linkname)
+		case tar.TypeXGlobalHeader:
+			// PAX global headers carry metadata only
+		default:
+			x.reject(header.Name, fmt.Sprintf("unsupported entry type %q", header.Typeflag))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// zip extracts a zip archive. Zip archives keep their index at the end, so they are read
+// from the downloaded file rather than streamed.
+func (x *extraction) zip(archivePath string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, entry := range zr.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.dir(entry.Name)
+		case mode&os.ModeSymlink != 0:
+			x.reject(entry.Name, "symbolic link")
+		case mode.IsRegular():
+			var rc io.ReadCloser
+			rc, err = entry.Open()
+			if err != nil {
+				return fmt.Errorf("failed to read %s from zip archive: %w", entry.Name, err)
+			}
+			err = x.file(entry.Name, rc)
+			rc.Close()
+		default:
+			x.reject(entry.Name, "unsupported entry type "+mode.Type().String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package download
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveEntry is an entry of a test archive. Entries with a link target are symbolic
+// links, or hard links if hard is set.
+type archiveEntry struct {
+	name     string
+	content  string
+	linkname string
+	hard     bool
+}
+
+// writeTarGz creates a tar.gz archive with the given entries
+func writeTarGz(t *testing.T, archivePath string, entries []archiveEntry) {
+	t.Helper()
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if entry.linkname != "" {
+			header.Size = 0
+			header.Linkname = entry.linkname
+			header.Typeflag = tar.TypeSymlink
+			if entry.hard {
+				header.Typeflag = tar.TypeLink
+			}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeZip creates a zip archive with the given entries. Zip archives store the target
+// of a symbolic link as its content.
+func writeZip(t *testing.T, archivePath string, entries []archiveEntry) {
+	t.Helper()
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(0644)
+		content := entry.content
+		if entry.linkname != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			content = entry.linkname
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractCSAFArchiveRejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		zip     bool
+		entries func(outside string) []archiveEntry
+		// links are symbolic links placed in the destination before extracting, mapping
+		// their path to a file or directory below outside
+		links     map[string]string
+		rejected  []string
+		extracted []string
+	}{
+		{
+			name: "tar symbolic link to a directory",
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "2024/link", linkname: outside},
+					{name: "2024/link/pwned.json", content: "{}"},
+					{name: "2024/a.json", content: "{}"},
+				}
+			},
+			rejected:  []string{"2024/link"},
+			extracted: []string{"2024/a.json", "2024/link/pwned.json"},
+		},
+		{
+			name: "tar symbolic link to a file",
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "a.json", linkname: filepath.Join(outside, "secret.json")},
+					{name: "a.json", content: "{}"},
+				}
+			},
+			rejected:  []string{"a.json"},
+			extracted: []string{"a.json"},
+		},
+		{
+			name: "tar hard link",
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "a.json", linkname: filepath.Join(outside, "secret.json"), hard: true},
+				}
+			},
+			rejected: []string{"a.json"},
+		},
+		{
+			name: "tar path traversal",
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "../outside/escape.json", content: "{}"},
+					{name: filepath.Join(outside, "absolute.json"), content: "{}"},
+					{name: "metadata.json", content: "{}"},
+					{name: ".quarantine/a.json", content: "{}"},
+				}
+			},
+			rejected: []string{"../outside/escape.json", "metadata.json", ".quarantine/a.json"},
+		},
+		{
+			name: "zip symbolic link",
+			zip:  true,
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "link", linkname: outside},
+					{name: "link/pwned.json", content: "{}"},
+					{name: "a.json", content: "{}"},
+				}
+			},
+			rejected:  []string{"link"},
+			extracted: []string{"a.json", "link/pwned.json"},
+		},
+		{
+			name: "zip path traversal",
+			zip:  true,
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "../outside/escape.json", content: "{}"},
+					{name: `..\outside\escape.json`, content: "{}"},
+				}
+			},
+			rejected: []string{"../outside/escape.json", `..\outside\escape.json`},
+		},
+		{
+			name: "existing symbolic link to a directory",
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "2024/a.json", content: "{}"},
+					{name: "2024/b.json", content: "{}"},
+				}
+			},
+			links:    map[string]string{"2024": "."},
+			rejected: []string{"2024/a.json", "2024/b.json"},
+		},
+		{
+			name: "existing symbolic link to a file",
+			zip:  true,
+			entries: func(outside string) []archiveEntry {
+				return []archiveEntry{
+					{name: "a.json", content: "{}"},
+					{name: "b.json", content: "{}"},
+				}
+			},
+			links:     map[string]string{"a.json": "secret.json"},
+			rejected:  []string{"a.json"},
+			extracted: []string{"b.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			destination := filepath.Join(root, "data")
+			outside := filepath.Join(root, "outside")
+			for _, dir := range []string{destination, outside} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			secretPath := filepath.Join(outside, "secret.json")
+			if err := os.WriteFile(secretPath, []byte("secret"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for link, target := range tt.links {
+				if err := os.Symlink(filepath.Join(outside, target), filepath.Join(destination, link)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			archivePath := filepath.Join(t.TempDir(), "csaf.tar.gz")
+			if tt.zip {
+				archivePath = filepath.Join(t.TempDir(), "csaf.zip")
+				writeZip(t, archivePath, tt.entries(outside))
+			} else {
+				writeTarGz(t, archivePath, tt.entries(outside))
+			}
+
+			output := captureStdout(t, func() {
+				if err := ExtractCSAFArchive(archivePath, destination); err != nil {
+					t.Errorf("ExtractCSAFArchive() error = %v", err)
+				}
+			})
+
+			for _, name := range tt.rejected {
+				if want := fmt.Sprintf("Warning: rejected archive entry %q", name); !strings.Contains(output, want) {
+					t.Errorf("rejection of %q not logged, output:\n%s", name, output)
+				}
+			}
+			if data, err := os.ReadFile(secretPath); err != nil || string(data) != "secret" {
+				t.Errorf("file outside the destination was modified: %q, %v", data, err)
+			}
+
+			want := []string{"outside/secret.json"}
+			for _, name := range tt.extracted {
+				want = append(want, "data/"+name)
+			}
+			for link := range tt.links {
+				want = append(want, "data/"+link)
+			}
+			assertFiles(t, root, want...)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+)
+
+// hashRetries is how often a document that does not match its published hash is downloaded again
+const hashRetries = 2
+
+// HashVerifier checks downloaded documents against the .sha512 or .sha256 files that
+// providers publish next to each document. Documents that keep failing are quarantined.
+type HashVerifier struct {
+	mu          sync.Mutex
+	verified    int
+	missing     int
+	quarantined map[string]string
+}
+
+// NewHashVerifier creates a hash verifier
+func NewHashVerifier() *HashVerifier {
+	return &HashVerifier{quarantined: make(map[string]string)}
+}
+
+// fetchHashFile downloads the strongest hash file published for a document and stores it next to the local copy
+func fetchHashFile(fileURL, localPath string) error {
+	for _, algorithm := range cache.HashAlgorithms {
+		data, notFound, err := fetchResource(fileURL+algorithm.Extension, true)
+		if err != nil {
+			return err
+		}
+		if notFound {
+			continue
+		}
+		if err := os.WriteFile(localPath+algorithm.Extension, data, 0644); err != nil {
+			return fmt.Errorf("failed to save hash file: %w", err)
+		}
+		return nil
+	}
+	return cache.ErrNoHash
+}
+
+// fetchSidecar stores a hash or signature file published at an arbitrary URL next to the local
+// copy of its document, named by the extension of the URL. Files that cannot be fetched are
+// skipped; verification then falls back to the URL of the document.
+func fetchSidecar(sidecarURL, localPath string) {
+	extension := ".asc"
+	if !strings.HasSuffix(sidecarURL, extension) {
+		extension = ""
+		for _, algorithm := range cache.HashAlgorithms {
+			if strings.HasSuffix(sidecarURL, algorithm.Extension) {
+				extension = algorithm.Extension
+			}
+		}
+		if extension == "" {
+			return
+		}
+	}
+
+	data, notFound, err := fetchResource(sidecarURL, true)
+	if err != nil || notFound {
+		return
+	}
+	os.WriteFile(localPath+extension, data, 0644)
+}
+
+// removeSidecars deletes hash and signature files stored next to a document
+func removeSidecars(localPath string) {
+	for _, algorithm := range cache.HashAlgorithms {
+		os.Remove(localPath + algorithm.Extension)
+	}
+	os.Remove(localPath + ".asc")
+}
+
+// checkHash verifies a document against its stored hash file, fetching the hash file first if it is missing
+func checkHash(fileURL, localPath string) error {
+	err := cache.VerifyFileHash(localPath)
+	if !errors.Is(err, cache.ErrNoHash) {
+		return err
+	}
+	if err := fetchHashFile(fileURL, localPath); err != nil {
+		return err
+	}
+	return cache.VerifyFileHash(localPath)
+}
+
+// VerifyFile checks a downloaded document against its published hash. On a mismatch the
+// document and its hash file are downloaded again up to hashRetries times before the
+// document is moved to the quarantine directory of the data set. Documents without a
+// published hash are accepted. An error is returned if the document was quarantined or
+// could not be checked, such as when its hash file failed to download; the latter is
+// not quarantined, so it is retried as a failed download by the next sync.
+func (h *HashVerifier) VerifyFile(fileURL, filePath, localPath, targetDir string) error {
+	err := checkHash(fileURL, localPath)
+	var mismatch *cache.HashMismatchError
+	for attempt := 1; attempt <= hashRetries && errors.As(err, &mismatch); attempt++ {
+		fmt.Printf("Hash verification of %s failed (%v), downloading it again (attempt %d/%d)\n",
+			filePath, err, attempt, hashRetries)
+		removeSidecars(localPath)
+		if err = downloadFile(fileURL, localPath); err == nil {
+			err = checkHash(fileURL, localPath)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case err == nil:
+		h.verified++
+	case errors.Is(err, cache.ErrNoHash):
+		h.missing++
+		err = nil
+	case errors.As(err, &mismatch):
+		if qErr := quarantine(targetDir, filePath); qErr != nil {
+			err = fmt.Errorf("%v (%w)", err, qErr)
+		}
+		h.quarantined[filePath] = err.Error()
+		return fmt.Errorf("quarantined %s: %w", filePath, err)
+	default:
+		return fmt.Errorf("failed to verify hash of %s: %w", filePath, err)
+	}
+
+	// The document is fine now, drop a quarantined copy from a previous sync
+	if _, ok := h.quarantined[filePath]; ok {
+		delete(h.quarantined, filePath)
+		quarantinePath := filepath.Join(targetDir, cache.QuarantineDir, filepath.FromSlash(filePath))
+		os.Remove(quarantinePath)
+		removeSidecars(quarantinePath)
+	}
+	return nil
+}
+
+// quarantine moves a document and its sidecar files out of the data set into its quarantine directory
+func quarantine(targetDir, filePath string) error {
+	localPath := filepath.Join(targetDir, filepath.FromSlash(filePath))
+	quarantinePath := filepath.Join(targetDir, cache.QuarantineDir, filepath.FromSlash(filePath))
+	if err := os.MkdirAll(filepath.Dir(quarantinePath), 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	if err := os.Rename(localPath, quarantinePath); err != nil {
+		return fmt.Errorf("failed to quarantine document: %w", err)
+	}
+	for _, algorithm := range cache.HashAlgorithms {
+		os.Rename(localPath+algorithm.Extension, quarantinePath+algorithm.Extension)
+	}
+	os.Rename(localPath+".asc", quarantinePath+".asc")
+	return nil
+}
+
+// Quarantined returns the quarantined documents, keyed by their path in the data set
+func (h *HashVerifier) Quarantined() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	quarantined := make(map[string]string, len(h.quarantined))
+	for path, reason := range h.quarantined {
+		quarantined[path] = reason
+	}
+	return quarantined
+}
+
+// MergeQuarantined adds documents quarantined by a previous sync, so they stay listed until
+// a good copy is downloaded
+func (h *HashVerifier) MergeQuarantined(previous map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for path, reason := range previous {
+		h.quarantined[path] = reason
+	}
+}
+
+// forget drops the quarantine entry of a document removed from the data set
+func (h *HashVerifier) forget(filePath string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.quarantined, filePath)
+}
+
+// PrintSummary prints the hash verification counts and the first few quarantined documents
+func (h *HashVerifier) PrintSummary() {
+	quarantined := h.Quarantined()
+	fmt.Printf("Verified %d hashes, %d documents have no published hash, %d documents are quarantined\n",
+		h.verified, h.missing, len(quarantined))
+
+	paths := make([]string, 0, len(quarantined))
+	for path := range quarantined {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	const maxListed = 10
+	for i, path := range paths {
+		if i == maxListed {
+			fmt.Printf("  ... and %d more (see quarantined in metadata.json)\n", len(paths)-maxListed)
+			break
+		}
+		fmt.Printf("  %s: %s\n", path, quarantined[path])
+	}
+}
+
+// verifyDocuments checks the hashes and signatures of all documents in a data set, such as
+// after extracting an archive, and records their TLP labels. Missing hash and signature
+// files are fetched from directoryURL.
+func verifyDocuments(directoryURL, targetDir string, opts Options, hashes *HashVerifier, signatures *SignatureVerifier,
+	labels *TLPRecorder) error {
+	documents, err := cache.ListDocuments(targetDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Verifying %d documents...\n", len(documents))
+	return forEachFile(directoryFileURL(directoryURL), documents, opts, "Verified", 100, func(fileURL, filePath string) error {
+		localPath := filepath.Join(targetDir, filepath.FromSlash(filePath))
+		if err := hashes.VerifyFile(fileURL, filePath, localPath, targetDir); err != nil {
+			return err
+		}
+		if signatures != nil {
+			signatures.VerifyFile(fileURL, filePath, localPath)
+		}
+		labels.Record(filePath, localPath)
+		return nil
+	})
+}
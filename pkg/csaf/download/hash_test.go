@@ -0,0 +1,161 @@
+package download
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIndividualFilesVerifiesHashes(t *testing.T) {
+	const document = `{"document": {"distribution": {"tlp": {"label": "WHITE"}}}}`
+	digest := sha512.Sum512([]byte(document))
+	goodHash := hex.EncodeToString(digest[:]) + "  a.json\n"
+	wrongHash := strings.Repeat("0", 128) + "  a.json\n"
+
+	tests := []struct {
+		name string
+		// sha512 is the status and body served for the .sha512 file
+		sha512Status int
+		sha512       string
+		wantErr      string
+		wantFiles    []string
+		// wantRequests is how often the document is downloaded
+		wantRequests    int
+		wantVerified    int
+		wantMissing     int
+		wantQuarantined bool
+	}{
+		{
+			name:         "matching hash",
+			sha512Status: http.StatusOK,
+			sha512:       goodHash,
+			wantFiles:    []string{"2024/a.json", "2024/a.json.sha512"},
+			wantRequests: 1,
+			wantVerified: 1,
+		},
+		{
+			name:         "no published hash",
+			sha512Status: http.StatusNotFound,
+			wantFiles:    []string{"2024/a.json"},
+			wantRequests: 1,
+			wantMissing:  1,
+		},
+		{
+			name:            "wrong hash",
+			sha512Status:    http.StatusOK,
+			sha512:          wrongHash,
+			wantErr:         "quarantined 2024/a.json: SHA-512 mismatch",
+			wantFiles:       []string{".quarantine/2024/a.json", ".quarantine/2024/a.json.sha512"},
+			wantRequests:    1 + hashRetries,
+			wantQuarantined: true,
+		},
+		{
+			name:         "server error on hash file",
+			sha512Status: http.StatusInternalServerError,
+			wantErr:      "failed to verify hash of 2024/a.json",
+			wantFiles:    []string{"2024/a.json"},
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetries(t)
+			var mu sync.Mutex
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/csaf/2024/a.json":
+					mu.Lock()
+					requests++
+					mu.Unlock()
+					fmt.Fprint(w, document)
+				case "/csaf/2024/a.json.sha512":
+					w.WriteHeader(tt.sha512Status)
+					fmt.Fprint(w, tt.sha512)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dataSet := t.TempDir()
+			hashes := NewHashVerifier()
+			var err error
+			captureStdout(t, func() {
+				err = IndividualFiles(server.URL+"/csaf/", []string{"2024/a.json"}, dataSet, Options{},
+					hashes, nil, NewTLPRecorder())
+			})
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("IndividualFiles() error = %v", err)
+				}
+			} else {
+				var failed FileErrors
+				if !errors.As(err, &failed) || failed["2024/a.json"] == nil {
+					t.Fatalf("IndividualFiles() error = %v, want a failed download of 2024/a.json", err)
+				}
+				if got := failed["2024/a.json"].Error(); !strings.Contains(got, tt.wantErr) {
+					t.Errorf("failed download error = %q, want %q", got, tt.wantErr)
+				}
+			}
+
+			assertFiles(t, dataSet, tt.wantFiles...)
+			if requests != tt.wantRequests {
+				t.Errorf("document downloaded %d times, want %d", requests, tt.wantRequests)
+			}
+			if hashes.verified != tt.wantVerified || hashes.missing != tt.wantMissing {
+				t.Errorf("verified %d, missing %d hashes, want %d, %d",
+					hashes.verified, hashes.missing, tt.wantVerified, tt.wantMissing)
+			}
+			if _, ok := hashes.Quarantined()["2024/a.json"]; ok != tt.wantQuarantined {
+				t.Errorf("quarantined = %v, want %v", ok, tt.wantQuarantined)
+			}
+		})
+	}
+}
+
+func TestVerifyFileKeepsDocumentWhenRedownloadFails(t *testing.T) {
+	noRetries(t)
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.json":
+			mu.Lock()
+			requests++
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/a.json.sha512":
+			fmt.Fprintf(w, "%s  a.json\n", strings.Repeat("0", 128))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dataSet := seedDataSet(t, server.URL+"/", nil, "a.json")
+	hashes := NewHashVerifier()
+	var err error
+	captureStdout(t, func() {
+		err = hashes.VerifyFile(server.URL+"/a.json", "a.json", filepath.Join(dataSet, "a.json"), dataSet)
+	})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+		t.Errorf("VerifyFile() error = %v, want the failed download", err)
+	}
+	if requests != 1 {
+		t.Errorf("document downloaded again %d times, want 1", requests)
+	}
+	if len(hashes.Quarantined()) != 0 {
+		t.Errorf("quarantined = %v, want none", hashes.Quarantined())
+	}
+	assertFiles(t, dataSet, "a.json")
+}
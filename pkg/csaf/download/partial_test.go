@@ -0,0 +1,127 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+)
+
+// directoryServer serves a CSAF directory without archive that lists a.json and b.json, with
+// an empty changes.csv. Requests for b.json fail while failing is set.
+func directoryServer(t *testing.T, failing *bool) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/csaf/changes.csv":
+			if r.Header.Get("If-None-Match") == `"changes-v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"changes-v1"`)
+		case "/csaf/index.txt":
+			fmt.Fprint(w, "2024/a.json\n2024/b.json\n")
+		case "/csaf/2024/a.json":
+			fmt.Fprint(w, `{"document": {}}`)
+		case "/csaf/2024/b.json":
+			if *failing {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, `{"document": {}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/csaf/"
+}
+
+func TestFullDownloadWithFailedDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		// lastSync is the time of the previous sync, zero if the data set was never downloaded
+		lastSync    time.Time
+		want        []string
+		wantErr     string
+		wantPartial bool
+	}{
+		{
+			name:     "stale cache",
+			lastSync: time.Now().Add(-30 * 24 * time.Hour),
+			want:     []string{"2024/a.json", "2024/old.json", "metadata.json"},
+			wantErr:  "previous data set kept",
+		},
+		{
+			name:        "first download",
+			want:        []string{"2024/a.json", "metadata.json"},
+			wantErr:     "partial data set kept",
+			wantPartial: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetries(t)
+			failing := true
+			directoryURL := directoryServer(t, &failing)
+
+			var metadata *cache.SyncMetadata
+			var documents []string
+			if !tt.lastSync.IsZero() {
+				metadata = &cache.SyncMetadata{LastSync: tt.lastSync, SourceURL: directoryURL}
+				documents = []string{"2024/a.json", "2024/old.json"}
+			}
+			dataSet := seedDataSet(t, directoryURL, metadata, documents...)
+
+			var err error
+			output := captureStdout(t, func() {
+				_, err = FromDirectoryURL(directoryURL, Options{})
+			})
+			var failed FileErrors
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.As(err, &failed) {
+				t.Fatalf("FromDirectoryURL() error = %v, want %q, output:\n%s", err, tt.wantErr, output)
+			}
+			assertFiles(t, dataSet, tt.want...)
+
+			got, err := cache.LoadSyncMetadata(dataSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Partial != tt.wantPartial {
+				t.Errorf("Partial = %v, want %v", got.Partial, tt.wantPartial)
+			}
+			if !tt.wantPartial {
+				// The previous sync metadata is left untouched
+				if !got.LastSync.Equal(tt.lastSync) || len(got.FailedDownloads) != 0 {
+					t.Errorf("metadata = %+v, want the metadata of the previous sync", got)
+				}
+				return
+			}
+			if strings.Join(got.FailedDownloads, " ") != "2024/b.json" {
+				t.Errorf("FailedDownloads = %v, want [2024/b.json]", got.FailedDownloads)
+			}
+
+			// The failed document is retried on the next sync, which completes the data set
+			failing = false
+			output = captureStdout(t, func() {
+				_, err = FromDirectoryURL(directoryURL, Options{})
+			})
+			if err != nil {
+				t.Fatalf("FromDirectoryURL() error = %v, output:\n%s", err, output)
+			}
+			assertFiles(t, dataSet, "2024/a.json", "2024/b.json", "metadata.json")
+			if got, err = cache.LoadSyncMetadata(dataSet); err != nil {
+				t.Fatal(err)
+			}
+			if got.Partial || len(got.FailedDownloads) != 0 {
+				t.Errorf("metadata = %+v, want a complete data set", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+)
+
+// safePath checks a file path taken from a remote index.txt, changes.csv or archive
+// entry and returns it in clean slash form. Paths that could resolve outside of the data
+// set, or that would overwrite the files csafx keeps in it, are rejected.
+func safePath(filePath string) (string, error) {
+	switch {
+	case filePath == "":
+		return "", errors.New("empty path")
+	case strings.ContainsRune(filePath, 0):
+		return "", errors.New("path contains a NUL byte")
+	case strings.Contains(filePath, `\`):
+		return "", errors.New("path contains a backslash")
+	case strings.HasPrefix(filePath, "/") || filepath.IsAbs(filePath) || filepath.VolumeName(filePath) != "":
+		return "", errors.New("absolute path")
+	}
+
+	for _, segment := range strings.Split(filePath, "/") {
+		if segment == ".." {
+			return "", errors.New("path contains a .. segment")
+		}
+	}
+
+	cleaned := path.Clean(filePath)
+	if cleaned == "." {
+		return "", errors.New("path does not name a file")
+	}
+	if cleaned == "metadata.json" || strings.SplitN(cleaned, "/", 2)[0] == cache.QuarantineDir {
+		return "", errors.New("path is reserved for csafx")
+	}
+	return cleaned, nil
+}
+
+// safePaths returns the safe paths of a list of remote file paths, logging every rejected one
+func safePaths(filePaths []string) []string {
+	var paths []string
+	for _, filePath := range filePaths {
+		cleaned, err := safePath(filePath)
+		if err != nil {
+			fmt.Printf("Warning: rejected unsafe file path %q: %v\n", filePath, err)
+			continue
+		}
+		paths = append(paths, cleaned)
+	}
+	return paths
+}
@@ -0,0 +1,173 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn prints to standard output, where csafx logs rejected paths
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-output
+}
+
+func TestSafePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+		wantErr  string
+	}{
+		{name: "plain file", filePath: "2024/cve-2024-0001.json", want: "2024/cve-2024-0001.json"},
+		{name: "redundant segments", filePath: "./2024//cve-2024-0001.json", want: "2024/cve-2024-0001.json"},
+		{name: "dot in name", filePath: "2024/..cve.json", want: "2024/..cve.json"},
+		{name: "empty", filePath: "", wantErr: "empty path"},
+		{name: "parent segment", filePath: "../cve.json", wantErr: ".. segment"},
+		{name: "inner parent segment", filePath: "2024/../../cve.json", wantErr: ".. segment"},
+		{name: "parent segment resolving inside", filePath: "2024/../cve.json", wantErr: ".. segment"},
+		{name: "trailing parent segment", filePath: "2024/..", wantErr: ".. segment"},
+		{name: "absolute", filePath: "/etc/passwd", wantErr: "absolute path"},
+		{name: "backslash", filePath: `2024\cve.json`, wantErr: "backslash"},
+		{name: "backslash traversal", filePath: `..\..\cve.json`, wantErr: "backslash"},
+		{name: "windows volume", filePath: `C:\cve.json`, wantErr: "backslash"},
+		{name: "NUL byte", filePath: "cve.json\x00.txt", wantErr: "NUL byte"},
+		{name: "current directory", filePath: ".", wantErr: "does not name a file"},
+		{name: "current directory with slash", filePath: "./", wantErr: "does not name a file"},
+		{name: "sync metadata", filePath: "metadata.json", wantErr: "reserved"},
+		{name: "sync metadata after cleaning", filePath: "./metadata.json", wantErr: "reserved"},
+		{name: "quarantine directory", filePath: ".quarantine", wantErr: "reserved"},
+		{name: "quarantined file", filePath: ".quarantine/2024/cve.json", wantErr: "reserved"},
+		{name: "nested metadata.json", filePath: "2024/metadata.json", want: "2024/metadata.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := safePath(tt.filePath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("safePath(%q) = %q, %v; want error containing %q", tt.filePath, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("safePath(%q) = %q, %v; want %q", tt.filePath, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafePaths(t *testing.T) {
+	filePaths := []string{
+		"2024/a.json",
+		"../escape.json",
+		"/etc/passwd",
+		`2024\b.json`,
+		"",
+		"metadata.json",
+		".quarantine/c.json",
+		"2024/d.json",
+	}
+
+	var got []string
+	output := captureStdout(t, func() {
+		got = safePaths(filePaths)
+	})
+
+	if want := []string{"2024/a.json", "2024/d.json"}; !slices.Equal(got, want) {
+		t.Errorf("safePaths() = %q, want %q", got, want)
+	}
+	for _, rejected := range filePaths[1:7] {
+		want := fmt.Sprintf("Warning: rejected unsafe file path %q", rejected)
+		if !strings.Contains(output, want) {
+			t.Errorf("rejection of %q not logged, output:\n%s", rejected, output)
+		}
+	}
+}
+
+func TestIndividualFilesRejectsUnsafePaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".json") {
+			fmt.Fprint(w, `{"document": {"distribution": {"tlp": {"label": "WHITE"}}}}`)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	dataSet := filepath.Join(root, "data")
+	if err := os.MkdirAll(dataSet, 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadataPath := filepath.Join(dataSet, "metadata.json")
+	if err := os.WriteFile(metadataPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	unsafe := []string{"../escape.json", "/tmp/absolute.json", `..\escape.json`, "metadata.json", ".quarantine/a.json"}
+	output := captureStdout(t, func() {
+		err := IndividualFiles(server.URL+"/csaf/", append([]string{"2024/a.json"}, unsafe...), dataSet,
+			Options{}, NewHashVerifier(), nil, NewTLPRecorder())
+		if err != nil {
+			t.Errorf("IndividualFiles() error = %v", err)
+		}
+	})
+
+	for _, filePath := range unsafe {
+		if want := fmt.Sprintf("Warning: rejected unsafe file path %q", filePath); !strings.Contains(output, want) {
+			t.Errorf("rejection of %q not logged, output:\n%s", filePath, output)
+		}
+	}
+	if data, err := os.ReadFile(metadataPath); err != nil || string(data) != "{}" {
+		t.Errorf("metadata.json was overwritten: %q, %v", data, err)
+	}
+	assertFiles(t, root, "data/2024/a.json", "data/metadata.json")
+}
+
+// assertFiles checks that the regular files and links below root are exactly the given ones
+func assertFiles(t *testing.T, root string, want ...string) {
+	t.Helper()
+	var got []string
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			rel, _ := filepath.Rel(root, filePath)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("files below %s = %q, want %q", root, got, want)
+	}
+}
@@ -0,0 +1,154 @@
+package download
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is the number of files downloaded in parallel if not configured otherwise
+const DefaultConcurrency = 8
+
+// FileErrors collects the files of a data set that could not be downloaded or verified,
+// keyed by their path in the data set
+type FileErrors map[string]error
+
+func (e FileErrors) Error() string {
+	return fmt.Sprintf("%d files failed", len(e))
+}
+
+// Print lists the first few failed files
+func (e FileErrors) Print() {
+	paths := e.Paths()
+
+	const maxListed = 10
+	for i, path := range paths {
+		if i == maxListed {
+			fmt.Printf("  ... and %d more\n", len(paths)-maxListed)
+			break
+		}
+		fmt.Printf("  %s: %v\n", path, e[path])
+	}
+}
+
+// Paths returns the failed files in sorted order
+func (e FileErrors) Paths() []string {
+	paths := make([]string, 0, len(e))
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// hostLimiter bounds the number of parallel requests to each host
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a request to the host of rawURL may be made and returns a function
+// that releases the slot
+func (l *hostLimiter) acquire(rawURL string) func() {
+	if l.limit <= 0 {
+		return func() {}
+	}
+
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	l.mu.Lock()
+	slot, ok := l.slots[host]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[host] = slot
+	}
+	l.mu.Unlock()
+
+	slot <- struct{}{}
+	return func() { <-slot }
+}
+
+// forEachFile calls fn for every file of a data set using a bounded number of workers.
+// fileURL maps the path of a file in the data set to the URL it is fetched from.
+// Progress is printed every progressEvery completed files and counts completions, so
+// the output does not depend on the order in which files finish. Failing files do not
+// stop the others; their errors are returned together.
+func forEachFile(fileURL func(filePath string) string, filePaths []string, opts Options, verb string, progressEvery int,
+	fn func(fileURL, filePath string) error) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	limiter := newHostLimiter(opts.MaxPerHost)
+
+	type result struct {
+		filePath string
+		err      error
+	}
+
+	jobs := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filePath := range jobs {
+				url := fileURL(filePath)
+				release := limiter.acquire(url)
+				err := fn(url, filePath)
+				release()
+				results <- result{filePath, err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, filePath := range filePaths {
+			jobs <- filePath
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	failed := make(FileErrors)
+	done := 0
+	for r := range results {
+		done++
+		if r.err != nil {
+			failed[r.filePath] = r.err
+		}
+		if done%progressEvery == 0 || done == len(filePaths) {
+			fmt.Printf("%s %d/%d files\n", verb, done, len(filePaths))
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// nonEmptyPaths drops blank entries from a list of file paths
+func nonEmptyPaths(filePaths []string) []string {
+	var paths []string
+	for _, filePath := range filePaths {
+		if strings.TrimSpace(filePath) != "" {
+			paths = append(paths, filePath)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,101 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SyncReport lists the documents changed by an incremental update
+type SyncReport struct {
+	Added   []string
+	Updated []string
+	// Removed are the documents no longer listed in the remote index.txt
+	Removed []string
+	// Reconciled is set if the data set was compared with the remote index.txt
+	Reconciled bool
+}
+
+// Print prints the number of added, updated and removed documents
+func (r *SyncReport) Print(keepRemoved bool) {
+	removed := "removed"
+	if keepRemoved {
+		removed = "withdrawn upstream and kept"
+	}
+	fmt.Printf("Sync summary: %d added, %d updated, %d %s\n", len(r.Added), len(r.Updated), len(r.Removed), removed)
+}
+
+// parseIndex returns the safe document paths listed in an index.txt file
+func parseIndex(data []byte) []string {
+	var files []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return safePaths(files)
+}
+
+// reconcileIndex compares the local documents of a data set with the remote index.txt. It
+// returns the documents that are listed remotely but missing locally, and the local documents
+// that are no longer listed. An empty index.txt is rejected rather than emptying the data set.
+func reconcileIndex(local []string, indexData []byte) (missing, removed []string, err error) {
+	remote := parseIndex(indexData)
+	if len(remote) == 0 {
+		return nil, nil, fmt.Errorf("index.txt does not list any documents")
+	}
+	missing, removed = reconcile(local, remote)
+	return missing, removed, nil
+}
+
+// reconcile returns the remote documents that are missing locally and the local documents
+// that are no longer listed remotely
+func reconcile(local, remote []string) (missing, removed []string) {
+	remoteSet := make(map[string]struct{}, len(remote))
+	for _, filePath := range remote {
+		remoteSet[filePath] = struct{}{}
+	}
+	localSet := make(map[string]struct{}, len(local))
+	for _, filePath := range local {
+		localSet[filePath] = struct{}{}
+		if _, ok := remoteSet[filePath]; !ok {
+			removed = append(removed, filePath)
+		}
+	}
+	for _, filePath := range remote {
+		if _, ok := localSet[filePath]; !ok {
+			missing = append(missing, filePath)
+		}
+	}
+	return missing, removed
+}
+
+// removeDocuments deletes documents together with their hash and signature files
+func removeDocuments(targetDir string, filePaths []string) {
+	for _, filePath := range filePaths {
+		localPath := filepath.Join(targetDir, filepath.FromSlash(filePath))
+		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to remove %s: %v\n", filePath, err)
+			continue
+		}
+		removeSidecars(localPath)
+	}
+}
+
+// markRemoved records documents that were withdrawn upstream but are kept in the cache,
+// keeping the time a document was first found missing. Documents listed again are unmarked.
+func markRemoved(previous map[string]time.Time, removed []string) map[string]time.Time {
+	marked := make(map[string]time.Time, len(removed))
+	now := time.Now()
+	for _, filePath := range removed {
+		if since, ok := previous[filePath]; ok {
+			marked[filePath] = since
+		} else {
+			marked[filePath] = now
+		}
+	}
+	return marked
+}
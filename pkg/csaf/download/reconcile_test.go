@@ -0,0 +1,115 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+)
+
+// seedDataSet creates the cached data set of a directory URL with the given documents and
+// sync metadata, and returns its path
+func seedDataSet(t *testing.T, directoryURL string, metadata *cache.SyncMetadata, documents ...string) string {
+	t.Helper()
+	cachePath := t.TempDir()
+	t.Setenv("CSAFX_CACHE_DIR", cachePath)
+	dataSet := filepath.Join(cachePath, urlToDirectoryName(directoryURL))
+	for _, document := range documents {
+		localPath := filepath.Join(dataSet, filepath.FromSlash(document))
+		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(localPath, []byte(`{"document": {}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if metadata != nil {
+		if err := os.MkdirAll(dataSet, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.SaveSyncMetadata(dataSet, metadata); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dataSet
+}
+
+func TestFromDirectoryURLReconcilesWithUnchangedChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		indexETag string
+		want      []string
+		wantLog   string
+	}{
+		{
+			name:      "index.txt unchanged",
+			indexETag: `"index-v1"`,
+			want:      []string{"2024/a.json", "2024/b.json", "metadata.json"},
+			wantLog:   "data set is up to date",
+		},
+		{
+			name:      "entry removed from index.txt",
+			indexETag: `"index-v2"`,
+			want:      []string{"2024/a.json", "metadata.json"},
+			wantLog:   "Sync summary: 0 added, 0 updated, 1 removed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetries(t)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/csaf/changes.csv":
+					w.WriteHeader(http.StatusNotModified)
+				case "/csaf/index.txt":
+					if r.Header.Get("If-None-Match") == tt.indexETag {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+					w.Header().Set("ETag", tt.indexETag)
+					fmt.Fprint(w, "2024/a.json\n")
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			directoryURL := server.URL + "/csaf/"
+			dataSet := seedDataSet(t, directoryURL, &cache.SyncMetadata{
+				LastSync:  time.Now().Add(-time.Hour),
+				SourceURL: directoryURL,
+				Validators: map[string]cache.HTTPValidators{
+					directoryURL + "changes.csv": {ETag: `"changes-v1"`},
+					directoryURL + "index.txt":   {ETag: `"index-v1"`},
+				},
+			}, "2024/a.json", "2024/b.json")
+
+			var err error
+			output := captureStdout(t, func() {
+				_, err = FromDirectoryURL(directoryURL, Options{})
+			})
+			if err != nil {
+				t.Fatalf("FromDirectoryURL() error = %v, output:\n%s", err, output)
+			}
+			if !strings.Contains(output, tt.wantLog) {
+				t.Errorf("output does not contain %q:\n%s", tt.wantLog, output)
+			}
+			assertFiles(t, dataSet, tt.want...)
+
+			metadata, err := cache.LoadSyncMetadata(dataSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := metadata.Validators[directoryURL+"index.txt"].ETag; got != tt.indexETag {
+				t.Errorf("stored index.txt ETag = %s, want %s", got, tt.indexETag)
+			}
+		})
+	}
+}
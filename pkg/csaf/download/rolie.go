@@ -0,0 +1,514 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+)
+
+// Feed is a ROLIE feed of CSAF documents as described in section 7.1.20 of the CSAF
+// specification. Both the JSON serialization used by CSAF providers and Atom XML are read.
+type Feed struct {
+	URL     string
+	Title   string
+	Updated time.Time
+	// TLPLabel is the TLP label declared by a category of the feed, if any
+	TLPLabel string
+	Entries  []FeedEntry
+}
+
+// FeedEntry is a single document listed in a ROLIE feed
+type FeedEntry struct {
+	ID      string
+	Updated time.Time
+	// URL is the location of the CSAF document
+	URL string
+	// FilePath is the path of the document in the data set, relative to the feed
+	FilePath string
+	// HashURLs and SignatureURL are the hash and signature files linked from the entry
+	HashURLs     []string
+	SignatureURL string
+}
+
+// Sidecars returns the URLs of the hash and signature files linked from the entry
+func (e FeedEntry) Sidecars() []string {
+	sidecars := append([]string(nil), e.HashURLs...)
+	if e.SignatureURL != "" {
+		sidecars = append(sidecars, e.SignatureURL)
+	}
+	return sidecars
+}
+
+// rolieLink, rolieCategory and rolieEntry map both the JSON and the Atom XML form of a feed
+type rolieLink struct {
+	Rel  string `json:"rel" xml:"rel,attr"`
+	Href string `json:"href" xml:"href,attr"`
+}
+
+type rolieCategory struct {
+	Scheme string `json:"scheme" xml:"scheme,attr"`
+	Term   string `json:"term" xml:"term,attr"`
+}
+
+type rolieEntry struct {
+	ID       string          `json:"id" xml:"id"`
+	Updated  string          `json:"updated" xml:"updated"`
+	Link     []rolieLink     `json:"link" xml:"link"`
+	Category []rolieCategory `json:"category" xml:"category"`
+	Content  struct {
+		Type string `json:"type" xml:"type,attr"`
+		Src  string `json:"src" xml:"src,attr"`
+	} `json:"content" xml:"content"`
+}
+
+type rolieFeed struct {
+	ID       string          `json:"id" xml:"id"`
+	Title    string          `json:"title" xml:"title"`
+	Updated  string          `json:"updated" xml:"updated"`
+	Category []rolieCategory `json:"category" xml:"category"`
+	Entry    []rolieEntry    `json:"entry" xml:"entry"`
+}
+
+// FetchFeed downloads and parses a ROLIE feed
+func FetchFeed(feedURL string) (*Feed, error) {
+	data, _, err := fetchResource(feedURL, false)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFeed(feedURL, data)
+}
+
+// ParseFeed parses a ROLIE feed in JSON or Atom XML form. Relative links are resolved
+// against feedURL. Entries without a link to a JSON document are skipped.
+func ParseFeed(feedURL string, data []byte) (*Feed, error) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL %s: %w", feedURL, err)
+	}
+
+	var raw rolieFeed
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+		if err := xml.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse ROLIE feed %s: %w", feedURL, err)
+		}
+	} else {
+		var doc struct {
+			Feed rolieFeed `json:"feed"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse ROLIE feed %s: %w", feedURL, err)
+		}
+		raw = doc.Feed
+	}
+
+	feed := &Feed{
+		URL:      feedURL,
+		Title:    strings.TrimSpace(raw.Title),
+		Updated:  parseFeedTime(raw.Updated),
+		TLPLabel: tlpCategory(raw.Category),
+	}
+	for _, e := range raw.Entry {
+		entry := FeedEntry{ID: strings.TrimSpace(e.ID), Updated: parseFeedTime(e.Updated)}
+		for _, link := range e.Link {
+			href := resolveLink(base, link.Href)
+			switch {
+			case href == "":
+			case link.Rel == "hash":
+				entry.HashURLs = append(entry.HashURLs, href)
+			case link.Rel == "signature":
+				entry.SignatureURL = href
+			case (link.Rel == "self" || link.Rel == "alternate") && entry.URL == "":
+				entry.URL = href
+			}
+		}
+		if src := resolveLink(base, e.Content.Src); src != "" {
+			entry.URL = src
+		}
+		if entry.URL == "" {
+			continue
+		}
+
+		filePath, err := safePath(feedFilePath(base, entry.URL))
+		if err != nil {
+			fmt.Printf("Warning: rejected unsafe feed entry %s: %v\n", entry.URL, err)
+			continue
+		}
+		entry.FilePath = filePath
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
+
+// parseFeedTime parses an RFC 3339 timestamp of a feed, returning the zero time if it is invalid
+func parseFeedTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// tlpCategory returns the TLP label of the first category whose term names one, such as "TLP:WHITE"
+func tlpCategory(categories []rolieCategory) string {
+	for _, category := range categories {
+		if label, ok := strings.CutPrefix(strings.ToUpper(strings.TrimSpace(category.Term)), "TLP:"); ok {
+			return label
+		}
+	}
+	return ""
+}
+
+// resolveLink resolves a possibly relative link of a feed
+func resolveLink(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(u).String()
+}
+
+// feedFilePath determines where a document linked from a feed is stored in the data set.
+// Documents below the directory of the feed keep their relative path, as they would in a
+// CSAF directory; documents elsewhere are stored under their host and path, so that files
+// of the same name on different hosts do not overwrite each other.
+func feedFilePath(base *url.URL, documentURL string) string {
+	u, err := url.Parse(documentURL)
+	if err != nil {
+		return ""
+	}
+	feedDir := path.Dir(base.Path) + "/"
+	if u.Scheme == base.Scheme && u.Host == base.Host {
+		if relative, ok := strings.CutPrefix(u.Path, feedDir); ok {
+			return relative
+		}
+	}
+	// Ports are separated by an underscore, as colons are not allowed in Windows file names
+	return strings.ReplaceAll(u.Host, ":", "_") + u.Path
+}
+
+// entryPaths returns the data set paths of feed entries, dropping entries that share a path
+// with an earlier one
+func entryPaths(entries []FeedEntry) (map[string]FeedEntry, []string) {
+	byPath := make(map[string]FeedEntry, len(entries))
+	var paths []string
+	for _, entry := range entries {
+		if _, ok := byPath[entry.FilePath]; ok {
+			fmt.Printf("Warning: feed lists %s more than once, using the first entry\n", entry.FilePath)
+			continue
+		}
+		byPath[entry.FilePath] = entry
+		paths = append(paths, entry.FilePath)
+	}
+	sort.Strings(paths)
+	return byPath, paths
+}
+
+// FeedEntries downloads the documents of feed entries to the target directory and verifies
+// them like IndividualFiles does, using the hash and signature files linked from the entries
+func FeedEntries(entries map[string]FeedEntry, filePaths []string, targetDir string, opts Options,
+	hashes *HashVerifier, signatures *SignatureVerifier, labels *TLPRecorder) error {
+	if len(filePaths) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Downloading %d documents from the feed...\n", len(filePaths))
+	fileURL := func(filePath string) string {
+		return entries[filePath].URL
+	}
+	return forEachFile(fileURL, filePaths, opts, "Downloaded", 10, func(fileURL, filePath string) error {
+		return fetchDocument(fileURL, filePath, targetDir, entries[filePath].Sidecars(), hashes, signatures, labels)
+	})
+}
+
+// warnTLPMismatch reports documents whose TLP label differs from the one declared by their feed
+func warnTLPMismatch(feed *Feed, labels *TLPRecorder) {
+	if feed.TLPLabel == "" {
+		return
+	}
+	mismatched := 0
+	for _, label := range labels.Labels() {
+		if label != feed.TLPLabel {
+			mismatched++
+		}
+	}
+	if mismatched > 0 {
+		fmt.Printf("Warning: %d documents do not carry the TLP:%s label of their feed\n", mismatched, feed.TLPLabel)
+	}
+}
+
+// FromRolieFeed downloads the CSAF documents listed in a ROLIE feed to the cache. An existing
+// data set is updated incrementally: documents whose entry was updated since the last sync,
+// documents missing locally and previously failed downloads are fetched, and documents no
+// longer listed in the feed are removed, unless opts.KeepRemoved is set.
+func FromRolieFeed(feedURL string, opts Options) (string, error) {
+	cachePath, err := cache.EnsureCachePath()
+	if err != nil {
+		return "", fmt.Errorf("failed to ensure cache path: %w", err)
+	}
+
+	dirName := urlToDirectoryName(feedURL)
+	targetPath := filepath.Join(cachePath, dirName)
+
+	if err := cache.CleanStagingDirs(dirName); err != nil {
+		return "", err
+	}
+
+	isValid, metadata, err := cache.IsValidCache(targetPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check cache validity: %w", err)
+	}
+
+	providerURL := opts.ProviderURL
+	if providerURL == "" && metadata != nil {
+		providerURL = metadata.ProviderURL
+	}
+
+	validators := make(map[string]cache.HTTPValidators)
+	var previous cache.HTTPValidators
+	if metadata != nil {
+		for url, v := range metadata.Validators {
+			validators[url] = v
+		}
+		// Failed downloads are looked up in the feed, so it is only fetched conditionally
+		// if there is nothing to retry
+		if isValid && len(metadata.FailedDownloads) == 0 {
+			previous = validators[feedURL]
+		}
+	}
+
+	fmt.Printf("Fetching ROLIE feed %s\n", feedURL)
+	result, err := fetchConditional(feedURL, previous)
+	if err == nil && result.NotFound {
+		err = fmt.Errorf("%s not found", feedURL)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch ROLIE feed: %w", err)
+	}
+	if result.NotModified {
+		fmt.Printf("No changes since last sync (%s), data set is up to date\n",
+			metadata.LastSync.Format(time.RFC3339))
+		metadata.LastSync = time.Now()
+		if err := cache.SaveSyncMetadata(targetPath, metadata); err != nil {
+			return "", fmt.Errorf("failed to save sync metadata: %w", err)
+		}
+		return targetPath, nil
+	}
+
+	feed, err := ParseFeed(feedURL, result.Data)
+	if err != nil {
+		return "", err
+	}
+	if len(feed.Entries) == 0 {
+		return "", fmt.Errorf("ROLIE feed %s does not list any documents", feedURL)
+	}
+	entries, filePaths := entryPaths(feed.Entries)
+	if feed.TLPLabel != "" {
+		fmt.Printf("Feed %q lists %d documents (TLP:%s)\n", feed.Title, len(filePaths), feed.TLPLabel)
+	} else {
+		fmt.Printf("Feed %q lists %d documents\n", feed.Title, len(filePaths))
+	}
+
+	hashes := NewHashVerifier()
+	labels := NewTLPRecorder()
+	var signatures *SignatureVerifier
+	if providerURL != "" {
+		fmt.Printf("Fetching OpenPGP keys of provider %s\n", providerURL)
+		signatures, err = newSignatureVerifier(providerURL)
+		if err != nil {
+			fmt.Printf("Warning: signatures will not be verified: %v\n", err)
+		}
+	} else {
+		fmt.Println("Warning: no provider metadata known for this feed, signatures will not be verified")
+	}
+
+	newMetadata := &cache.SyncMetadata{
+		SourceURL:   feedURL,
+		SourceType:  cache.SourceROLIE,
+		ProviderURL: providerURL,
+		Validators:  validators,
+	}
+
+	var failed FileErrors
+	var report *SyncReport
+	downloadPath := targetPath
+	if isValid && metadata != nil {
+		fmt.Printf("Valid cache found (last sync: %s), performing incremental update\n",
+			metadata.LastSync.Format(time.RFC3339))
+
+		hashes.MergeQuarantined(metadata.Quarantined)
+		labels.MergeLabels(metadata.TLPLabels)
+		if signatures != nil {
+			signatures.MergeFailures(metadata.SignatureFailures)
+		}
+
+		report, err = updateFromFeed(entries, filePaths, targetPath, metadata, opts, hashes, signatures, labels)
+		if err != nil && !errors.As(err, &failed) {
+			return "", fmt.Errorf("incremental update failed: %w", err)
+		}
+		if opts.KeepRemoved {
+			newMetadata.Removed = markRemoved(metadata.Removed, report.Removed)
+		}
+		newMetadata.Partial = metadata.Partial && len(failed) > 0
+	} else {
+		if metadata != nil {
+			fmt.Printf("Cache is stale (last sync: %s), performing full download\n",
+				metadata.LastSync.Format(time.RFC3339))
+		} else {
+			fmt.Println("No cache found, performing full download")
+		}
+
+		// Download into a staging directory, as FromDirectoryURL does
+		downloadPath, err = cache.CreateStagingDir(dirName)
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(downloadPath)
+
+		err = FeedEntries(entries, filePaths, downloadPath, opts, hashes, signatures, labels)
+		if err != nil && !errors.As(err, &failed) {
+			return "", fmt.Errorf("failed to download feed entries: %w", err)
+		}
+		if len(failed) > 0 && metadata != nil {
+			return targetPath, keepPreviousDataSet(targetPath, failed)
+		}
+		newMetadata.Partial = len(failed) > 0
+	}
+
+	recordValidators(validators, feedURL, result)
+	newMetadata.LastSync = time.Now()
+	newMetadata.Quarantined = hashes.Quarantined()
+	newMetadata.FailedDownloads = failed.Paths()
+	newMetadata.TLPLabels = labels.Labels()
+	if report != nil {
+		report.Print(opts.KeepRemoved)
+	}
+	hashes.PrintSummary()
+	labels.PrintSummary()
+	warnTLPMismatch(feed, labels)
+	if signatures != nil {
+		newMetadata.SignatureFailures = signatures.Failures()
+		signatures.PrintSummary()
+	}
+	if err := cache.SaveSyncMetadata(downloadPath, newMetadata); err != nil {
+		return "", fmt.Errorf("failed to save sync metadata: %w", err)
+	}
+
+	kind := "Incremental update"
+	if downloadPath != targetPath {
+		kind = "Full download"
+		if err := cache.CommitStagingDir(downloadPath, targetPath); err != nil {
+			return "", err
+		}
+	}
+
+	if len(failed) > 0 {
+		if downloadPath != targetPath {
+			return targetPath, partialDataSet(targetPath, failed)
+		}
+		fmt.Printf("Incremental update completed, %d files failed and will be retried on the next sync:\n", len(failed))
+		failed.Print()
+		return targetPath, fmt.Errorf("incremental update incomplete: %w", failed)
+	}
+
+	fmt.Printf("%s completed successfully\n", kind)
+	return targetPath, nil
+}
+
+// updateFromFeed brings an existing data set in line with a feed. Entries updated since the
+// last sync are downloaded again, using the updated timestamps of the feed in place of
+// changes.csv.
+func updateFromFeed(entries map[string]FeedEntry, filePaths []string, targetDir string, metadata *cache.SyncMetadata,
+	opts Options, hashes *HashVerifier, signatures *SignatureVerifier, labels *TLPRecorder) (*SyncReport, error) {
+	fmt.Printf("Performing incremental update (changes since %s)\n", metadata.LastSync.Format(time.RFC3339))
+	report := &SyncReport{Reconciled: true}
+
+	local, err := cache.ListDocuments(targetDir)
+	if err != nil {
+		return report, err
+	}
+	localSet := make(map[string]struct{}, len(local))
+	for _, filePath := range local {
+		localSet[filePath] = struct{}{}
+	}
+
+	missing, removed := reconcile(local, filePaths)
+	report.Removed = removed
+
+	changed := make(map[string]struct{})
+	for _, filePath := range missing {
+		changed[filePath] = struct{}{}
+	}
+	for _, filePath := range metadata.FailedDownloads {
+		if _, ok := entries[filePath]; ok {
+			changed[filePath] = struct{}{}
+		}
+	}
+	for filePath, entry := range entries {
+		if entry.Updated.After(metadata.LastSync) {
+			changed[filePath] = struct{}{}
+		}
+	}
+
+	changedFiles := make([]string, 0, len(changed))
+	for filePath := range changed {
+		changedFiles = append(changedFiles, filePath)
+	}
+	sort.Strings(changedFiles)
+
+	if len(changedFiles) == 0 && len(removed) == 0 {
+		fmt.Println("No files have changed since last sync")
+		return report, nil
+	}
+
+	var failed FileErrors
+	if len(changedFiles) > 0 {
+		fmt.Printf("Found %d files to update\n", len(changedFiles))
+		err = FeedEntries(entries, changedFiles, targetDir, opts, hashes, signatures, labels)
+		if err != nil && !errors.As(err, &failed) {
+			return report, err
+		}
+	}
+
+	for _, filePath := range changedFiles {
+		if _, ok := failed[filePath]; ok {
+			continue
+		}
+		if _, ok := localSet[filePath]; ok {
+			report.Updated = append(report.Updated, filePath)
+		} else {
+			report.Added = append(report.Added, filePath)
+		}
+	}
+
+	if len(removed) > 0 && !opts.KeepRemoved {
+		fmt.Printf("Removing %d documents that are no longer listed in the feed\n", len(removed))
+		removeDocuments(targetDir, removed)
+		for _, filePath := range removed {
+			hashes.forget(filePath)
+			labels.forget(filePath)
+			if signatures != nil {
+				signatures.forget(filePath)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return report, failed
+	}
+	return report, nil
+}
@@ -0,0 +1,188 @@
+package download
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// SignatureVerifier verifies the detached OpenPGP signatures (.asc files) that providers
+// publish next to each document and records the outcome for every file
+type SignatureVerifier struct {
+	keyring openpgp.EntityList
+
+	mu       sync.Mutex
+	verified int
+	failures map[string]string
+}
+
+// NewSignatureVerifier fetches the public OpenPGP keys listed in the provider metadata. Keys
+// whose fingerprint does not match the one in the metadata are rejected.
+func NewSignatureVerifier(keys []OpenPGPKey) (*SignatureVerifier, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("provider metadata does not list any public OpenPGP keys")
+	}
+
+	var keyring openpgp.EntityList
+	var keyErrors []string
+	for _, key := range keys {
+		entities, err := fetchOpenPGPKey(key)
+		if err != nil {
+			keyErrors = append(keyErrors, err.Error())
+			continue
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("no usable public OpenPGP keys: %s", strings.Join(keyErrors, "; "))
+	}
+	for _, keyError := range keyErrors {
+		fmt.Printf("Warning: %s\n", keyError)
+	}
+
+	return &SignatureVerifier{keyring: keyring, failures: make(map[string]string)}, nil
+}
+
+// fetchOpenPGPKey downloads a public key and checks its fingerprint against the provider metadata
+func fetchOpenPGPKey(key OpenPGPKey) (openpgp.EntityList, error) {
+	data, _, err := fetchResource(key.URL, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OpenPGP key: %w", err)
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse OpenPGP key %s: %w", key.URL, err)
+		}
+	}
+
+	if key.Fingerprint == "" {
+		return entities, nil
+	}
+
+	expected := strings.ToUpper(strings.ReplaceAll(key.Fingerprint, " ", ""))
+	for _, entity := range entities {
+		if strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)) == expected {
+			return openpgp.EntityList{entity}, nil
+		}
+	}
+	return nil, fmt.Errorf("OpenPGP key %s does not match fingerprint %s", key.URL, key.Fingerprint)
+}
+
+// Verify checks a document against its armored detached signature
+func (v *SignatureVerifier) Verify(document, signature []byte) error {
+	_, err := openpgp.CheckArmoredDetachedSignature(v.keyring, bytes.NewReader(document), bytes.NewReader(signature), nil)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	return nil
+}
+
+// VerifyFile verifies a downloaded document. The signature is read from the .asc file
+// next to the document, or fetched from fileURL + ".asc" and saved there if it is missing.
+// The outcome is recorded under filePath.
+func (v *SignatureVerifier) VerifyFile(fileURL, filePath, localPath string) error {
+	err := v.verifyFile(fileURL, localPath)
+	v.record(filePath, err)
+	return err
+}
+
+func (v *SignatureVerifier) verifyFile(fileURL, localPath string) error {
+	document, err := os.ReadFile(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+
+	signaturePath := localPath + ".asc"
+	signature, err := os.ReadFile(signaturePath)
+	if os.IsNotExist(err) {
+		var notFound bool
+		signature, notFound, err = fetchResource(fileURL+".asc", true)
+		if err != nil {
+			return err
+		}
+		if notFound {
+			return fmt.Errorf("no signature published at %s.asc", fileURL)
+		}
+		if err := os.WriteFile(signaturePath, signature, 0644); err != nil {
+			return fmt.Errorf("failed to save signature: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	return v.Verify(document, signature)
+}
+
+// record stores the verification outcome of a single file
+func (v *SignatureVerifier) record(filePath string, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err != nil {
+		v.failures[filePath] = err.Error()
+		return
+	}
+	delete(v.failures, filePath)
+	v.verified++
+}
+
+// Failures returns the files that failed verification, keyed by their path in the data set
+func (v *SignatureVerifier) Failures() map[string]string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	failures := make(map[string]string, len(v.failures))
+	for path, reason := range v.failures {
+		failures[path] = reason
+	}
+	return failures
+}
+
+// MergeFailures adds failures recorded by a previous sync, so that files which are not
+// downloaded again keep their verification status
+func (v *SignatureVerifier) MergeFailures(previous map[string]string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for path, reason := range previous {
+		v.failures[path] = reason
+	}
+}
+
+// forget drops the verification failure of a document removed from the data set
+func (v *SignatureVerifier) forget(filePath string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.failures, filePath)
+}
+
+// PrintSummary prints the number of verified documents and the first few failures
+func (v *SignatureVerifier) PrintSummary() {
+	failures := v.Failures()
+	fmt.Printf("Verified %d signatures, %d documents failed signature verification\n", v.verified, len(failures))
+
+	paths := make([]string, 0, len(failures))
+	for path := range failures {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	const maxListed = 10
+	for i, path := range paths {
+		if i == maxListed {
+			fmt.Printf("  ... and %d more (see signature_failures in metadata.json)\n", len(paths)-maxListed)
+			break
+		}
+		fmt.Printf("  %s: %s\n", path, failures[path])
+	}
+}
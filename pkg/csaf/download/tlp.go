@@ -0,0 +1,118 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TLPRecorder records the TLP label of every downloaded document, so restricted documents
+// can be told apart from public ones in the cache
+type TLPRecorder struct {
+	mu     sync.Mutex
+	labels map[string]string
+}
+
+// NewTLPRecorder creates an empty TLP recorder
+func NewTLPRecorder() *TLPRecorder {
+	return &TLPRecorder{labels: make(map[string]string)}
+}
+
+// readTLPLabel returns the TLP label of a document, or an empty string if it has none
+func readTLPLabel(localPath string) (string, error) {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read document: %w", err)
+	}
+
+	var doc struct {
+		Document struct {
+			Distribution struct {
+				TLP struct {
+					Label string `json:"label"`
+				} `json:"tlp"`
+			} `json:"distribution"`
+		} `json:"document"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("failed to parse document: %w", err)
+	}
+	return strings.ToUpper(doc.Document.Distribution.TLP.Label), nil
+}
+
+// Record reads the TLP label of a downloaded document. Documents without a label are
+// recorded with an empty label; documents that cannot be parsed are not recorded.
+func (r *TLPRecorder) Record(filePath, localPath string) {
+	label, err := readTLPLabel(localPath)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		delete(r.labels, filePath)
+		return
+	}
+	r.labels[filePath] = label
+}
+
+// Labels returns the recorded TLP labels, keyed by the document path in the data set
+func (r *TLPRecorder) Labels() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	labels := make(map[string]string, len(r.labels))
+	for path, label := range r.labels {
+		labels[path] = label
+	}
+	return labels
+}
+
+// MergeLabels adds the labels recorded by a previous sync for documents that are not
+// downloaded again
+func (r *TLPRecorder) MergeLabels(previous map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for path, label := range previous {
+		r.labels[path] = label
+	}
+}
+
+// forget drops the label of a document removed from the data set
+func (r *TLPRecorder) forget(filePath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.labels, filePath)
+}
+
+// PrintSummary prints the number of documents per TLP label
+func (r *TLPRecorder) PrintSummary() {
+	counts := make(map[string]int)
+	for _, label := range r.Labels() {
+		if label == "" {
+			label = "unlabeled"
+		} else {
+			label = "TLP:" + label
+		}
+		counts[label]++
+	}
+
+	if len(counts) == 0 {
+		return
+	}
+
+	labels := make([]string, 0, len(counts))
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = fmt.Sprintf("%d %s", counts[label], label)
+	}
+	fmt.Printf("TLP labels: %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Config configures the transport of a client
+type Config struct {
+	// Timeout bounds connecting, the TLS handshake, waiting for the response headers and
+	// waiting for more data of the response body. The total time to read a body is not
+	// limited, so large archives can be downloaded.
+	Timeout time.Duration
+	// Proxy is the URL of a proxy used for all requests. If empty, the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables are used.
+	Proxy string
+	// CACertFile is a PEM bundle of certificate authorities trusted in addition to the
+	// system ones, such as the CA of a TLS intercepting proxy
+	CACertFile string
+	// ClientCertFile and ClientKeyFile are a PEM certificate and key presented to
+	// servers that require mutual TLS
+	ClientCertFile string
+	ClientKeyFile  string
+	// Credentials are sent to the providers whose URLs they match
+	Credentials []Credentials
+	Retry       RetryPolicy
+	// Log receives notices about retried requests. If nil, they are written to standard error.
+	Log io.Writer
+}
+
+// DefaultConfig is used unless another configuration is set
+var DefaultConfig = Config{
+	Timeout: 30 * time.Second,
+	Retry:   DefaultRetryPolicy,
+}
+
+// New creates a client with the given configuration
+func New(cfg Config) (*Client, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := newAuthenticators(cfg.Credentials, transport)
+	if err != nil {
+		return nil, err
+	}
+	log := cfg.Log
+	if log == nil {
+		log = os.Stderr
+	}
+	client := &Client{HTTP: &http.Client{Transport: transport}, Retry: cfg.Retry, Log: log, readTimeout: cfg.Timeout, auth: auth}
+	client.HTTP.CheckRedirect = client.checkRedirect
+	for _, a := range client.auth {
+		if a.http != nil {
+			a.http.CheckRedirect = client.checkRedirect
+		}
+	}
+	return client, nil
+}
+
+// newTransport builds an HTTP transport with the configured proxy, timeouts and TLS settings
+func newTransport(cfg Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL %s: %w", cfg.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.Timeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: cfg.Timeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = cfg.Timeout
+		transport.ResponseHeaderTimeout = cfg.Timeout
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// newTLSConfig loads the extra CA bundle and client certificate of the configuration
+func newTLSConfig(cfg Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CACertFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		if cfg.ClientCertFile == "" || cfg.ClientKeyFile == "" {
+			return nil, fmt.Errorf("a client certificate requires both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
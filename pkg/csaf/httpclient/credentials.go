@@ -0,0 +1,252 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Credentials authenticate requests to the URLs of a single provider, such as the
+// distributions of TLP:AMBER and TLP:RED documents that require a login
+type Credentials struct {
+	// URL is the prefix of the URLs the credentials are sent to, for example
+	// https://example.com/.well-known/csaf/ for all distributions of a provider
+	URL string `yaml:"url"`
+	// Username and Password are sent using HTTP basic authentication
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// Token is sent as a bearer token in the Authorization header
+	Token string `yaml:"token,omitempty"`
+	// Headers are added to every request, such as API keys in custom headers
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// ClientCertFile and ClientKeyFile are a PEM certificate and key presented only to this provider
+	ClientCertFile string `yaml:"client_cert,omitempty"`
+	ClientKeyFile  string `yaml:"client_key,omitempty"`
+}
+
+// credentialsFile is the format of the credentials file
+type credentialsFile struct {
+	Providers []Credentials `yaml:"providers"`
+}
+
+// Environment variables that configure credentials without a file
+const (
+	CredentialsFileEnv = "CSAFX_CREDENTIALS"
+	authURLEnv         = "CSAFX_AUTH_URL"
+	authUsernameEnv    = "CSAFX_AUTH_USERNAME"
+	authPasswordEnv    = "CSAFX_AUTH_PASSWORD"
+	authTokenEnv       = "CSAFX_AUTH_TOKEN"
+)
+
+// DefaultCredentialsPath returns the credentials file used if no other file is configured
+func DefaultCredentialsPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "csafx", "credentials.yaml")
+}
+
+// LoadCredentials reads a YAML credentials file. References to environment variables such
+// as ${ACME_TOKEN} are expanded, so secrets do not have to be stored in the file.
+func LoadCredentials(path string) ([]Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var file credentialsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", path, err)
+	}
+
+	for i := range file.Providers {
+		creds := &file.Providers[i]
+		if creds.URL == "" {
+			return nil, fmt.Errorf("credentials file %s: entry %d has no url", path, i+1)
+		}
+		creds.ExpandEnv()
+	}
+	return file.Providers, nil
+}
+
+// ExpandEnv replaces references to environment variables such as ${ACME_TOKEN} in the secrets
+func (c *Credentials) ExpandEnv() {
+	c.Username = os.ExpandEnv(c.Username)
+	c.Password = os.ExpandEnv(c.Password)
+	c.Token = os.ExpandEnv(c.Token)
+	for name, value := range c.Headers {
+		c.Headers[name] = os.ExpandEnv(value)
+	}
+}
+
+// CredentialsFromEnv returns the credentials configured by the CSAFX_AUTH_* environment
+// variables, or nil if CSAFX_AUTH_URL is not set
+func CredentialsFromEnv() *Credentials {
+	authURL := os.Getenv(authURLEnv)
+	if authURL == "" {
+		return nil
+	}
+	return &Credentials{
+		URL:      authURL,
+		Username: os.Getenv(authUsernameEnv),
+		Password: os.Getenv(authPasswordEnv),
+		Token:    os.Getenv(authTokenEnv),
+	}
+}
+
+// LookupCredentials loads the credentials from the given file, the file named by
+// CSAFX_CREDENTIALS or the default credentials file, in that order, followed by the
+// credentials from the environment. A missing default file is not an error.
+func LookupCredentials(path string) ([]Credentials, error) {
+	if path == "" {
+		path = os.Getenv(CredentialsFileEnv)
+	}
+	if path == "" {
+		path = DefaultCredentialsPath()
+		if _, err := os.Stat(path); err != nil {
+			path = ""
+		}
+	}
+
+	var credentials []Credentials
+	if path != "" {
+		var err error
+		credentials, err = LoadCredentials(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if creds := CredentialsFromEnv(); creds != nil {
+		credentials = append(credentials, *creds)
+	}
+	return credentials, nil
+}
+
+// matches reports whether the credentials apply to a request URL. The scheme and host
+// must be equal and the path must start with the path of the credentials URL.
+func (c *Credentials) matches(prefix *url.URL, u *url.URL) bool {
+	if !strings.EqualFold(prefix.Scheme, u.Scheme) || !strings.EqualFold(prefix.Host, u.Host) {
+		return false
+	}
+	path := strings.TrimSuffix(prefix.Path, "/")
+	return u.Path == path || strings.HasPrefix(u.Path, path+"/")
+}
+
+// apply adds the authentication headers to a request
+func (c *Credentials) apply(req *http.Request) {
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+}
+
+// remove deletes the authentication headers added by apply
+func (c *Credentials) remove(req *http.Request) {
+	for name := range c.Headers {
+		req.Header.Del(name)
+	}
+	if c.Token != "" || c.Username != "" {
+		req.Header.Del("Authorization")
+	}
+}
+
+// authenticator holds the credentials of a provider and the HTTP client used for it,
+// which differs from the shared one if the provider requires its own client certificate
+type authenticator struct {
+	credentials Credentials
+	prefix      *url.URL
+	http        *http.Client
+}
+
+// newAuthenticators prepares the credentials of all providers. Providers with a client
+// certificate get a copy of the base transport that presents it.
+func newAuthenticators(credentials []Credentials, base *http.Transport) ([]authenticator, error) {
+	var auths []authenticator
+	for _, creds := range credentials {
+		prefix, err := url.Parse(creds.URL)
+		if err != nil || prefix.Host == "" {
+			return nil, fmt.Errorf("invalid credentials URL %q", creds.URL)
+		}
+
+		auth := authenticator{credentials: creds, prefix: prefix}
+		if creds.ClientCertFile != "" || creds.ClientKeyFile != "" {
+			if creds.ClientCertFile == "" || creds.ClientKeyFile == "" {
+				return nil, fmt.Errorf("client certificate for %s requires both a certificate and a key file", creds.URL)
+			}
+			cert, err := tls.LoadX509KeyPair(creds.ClientCertFile, creds.ClientKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate for %s: %w", creds.URL, err)
+			}
+			transport := base.Clone()
+			transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+			auth.http = &http.Client{Transport: transport}
+		}
+		auths = append(auths, auth)
+	}
+	return auths, nil
+}
+
+// authenticate applies the credentials with the longest matching URL to a request and
+// returns the HTTP client to send it with
+func (c *Client) authenticate(req *http.Request) *http.Client {
+	match := c.match(req.URL)
+	if match == nil {
+		return c.HTTP
+	}
+	match.credentials.apply(req)
+	if match.http != nil {
+		return match.http
+	}
+	return c.HTTP
+}
+
+// match returns the authenticator with the longest URL matching u, or nil if there is none
+func (c *Client) match(u *url.URL) *authenticator {
+	var match *authenticator
+	for i := range c.auth {
+		auth := &c.auth[i]
+		if !auth.credentials.matches(auth.prefix, u) {
+			continue
+		}
+		if match == nil || len(auth.prefix.Path) > len(match.prefix.Path) {
+			match = auth
+		}
+	}
+	return match
+}
+
+// checkRedirect is the redirect policy of all clients. The headers of the credentials
+// sent with the original request are removed if the redirect target is outside their
+// URL, and the credentials matching the target are applied instead. Go itself only
+// removes the Authorization header, and only on redirects to another domain.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	previous, next := c.match(via[0].URL), c.match(req.URL)
+	if previous == next {
+		return nil
+	}
+	if previous != nil {
+		previous.credentials.remove(req)
+	}
+	if next != nil {
+		next.credentials.apply(req)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package httpclient provides the HTTP client shared by all commands that fetch
+// CSAF resources. It retries transient failures with exponential backoff and honors
+// the Retry-After header of rate limited and unavailable servers.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, zero disables retries
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry; it doubles with every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest Retry-After the client waits for; requests asking
+	// for a longer wait fail instead
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy is used unless another policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     4,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	MaxRetryAfter:  5 * time.Minute,
+}
+
+// Client is an HTTP client that retries transient failures
+type Client struct {
+	HTTP  *http.Client
+	Retry RetryPolicy
+	// Log receives notices about retried requests, nil discards them
+	Log io.Writer
+
+	readTimeout time.Duration
+	auth        []authenticator
+}
+
+var (
+	defaultMu sync.RWMutex
+	// The default configuration does not load any files and cannot fail
+	defaultClient, _ = New(DefaultConfig)
+)
+
+// Default returns the client used by all commands
+func Default() *Client {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultClient
+}
+
+// SetDefault replaces the client used by all commands
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = c
+}
+
+// Get issues a GET request using the default client
+func Get(url string) (*http.Response, error) {
+	return Default().Get(url)
+}
+
+// Do sends a request using the default client
+func Do(req *http.Request) (*http.Response, error) {
+	return Default().Do(req)
+}
+
+// Get issues a GET request, retrying transient failures
+func (c *Client) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends a request, retrying network errors, 429 Too Many Requests and 5xx responses.
+// The response of the last attempt is returned if all retries fail.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.GetBody == nil {
+		return nil, errors.New("cannot retry a request whose body cannot be replayed")
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Each attempt has its own context, so a stalled response body can be cancelled
+		ctx, cancel := context.WithCancel(req.Context())
+		attemptReq := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := c.authenticate(attemptReq).Do(attemptReq)
+		if attempt >= c.Retry.MaxRetries || !retryable(resp, err) {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = newIdleTimeoutBody(resp.Body, c.readTimeout, cancel)
+			return resp, nil
+		}
+
+		wait := c.backoff(attempt)
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > c.Retry.MaxRetryAfter {
+					resp.Body = newIdleTimeoutBody(resp.Body, c.readTimeout, cancel)
+					return resp, nil
+				}
+				wait = retryAfter
+			}
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+		cancel()
+
+		if c.Log != nil {
+			fmt.Fprintf(c.Log, "Request to %s failed (%s), retrying in %s (%d/%d)\n",
+				req.URL, reason, wait.Round(time.Millisecond), attempt+1, c.Retry.MaxRetries)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryable reports whether a failed attempt may succeed when repeated. Cancelled
+// requests and certificate errors are permanent; other network errors are not.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		return !errors.Is(err, context.Canceled) && !errors.As(err, &certErr)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the wait before the given retry: exponential growth capped at
+// MaxBackoff, with half of it randomized so that parallel downloads spread out
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.Retry.InitialBackoff << attempt
+	if wait <= 0 || wait > c.Retry.MaxBackoff {
+		wait = c.Retry.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimeoutBody is a response body that cancels its request if the server sends no
+// data for longer than the timeout. Slow transfers that keep making progress are not
+// interrupted, so the timeout also applies to large archives.
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+// newIdleTimeoutBody wraps a response body; cancel must cancel the context of its request.
+// A timeout of zero only releases the context when the body is closed.
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	b := &idleTimeoutBody{body: body, timeout: timeout, cancel: cancel}
+	if timeout > 0 {
+		b.timer = time.AfterFunc(timeout, func() {
+			b.expired.Store(true)
+			cancel()
+		})
+	}
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.timer == nil {
+		return n, err
+	}
+	if err != nil && b.expired.Load() {
+		return n, fmt.Errorf("no data received for %s: %w", b.timeout, os.ErrDeadlineExceeded)
+	}
+	b.timer.Reset(b.timeout)
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,69 @@
+package csaf
+
+// ProductTree contains all products referenced in the document
+type ProductTree struct {
+	Branches         []Branch          `json:"branches,omitempty"`
+	FullProductNames []FullProductName `json:"full_product_names,omitempty"`
+	ProductGroups    []ProductGroup    `json:"product_groups,omitempty"`
+	Relationships    []Relationship    `json:"relationships,omitempty"`
+}
+
+// Branch is a node in the product tree hierarchy (e.g. vendor, product name or version)
+type Branch struct {
+	Branches []Branch         `json:"branches,omitempty"`
+	Category string           `json:"category"`
+	Name     string           `json:"name"`
+	Product  *FullProductName `json:"product,omitempty"`
+}
+
+// FullProductName defines a product and assigns it a product ID
+type FullProductName struct {
+	Name                        string                       `json:"name"`
+	ProductID                   string                       `json:"product_id"`
+	ProductIdentificationHelper *ProductIdentificationHelper `json:"product_identification_helper,omitempty"`
+}
+
+// ProductIdentificationHelper provides identifiers that help locate a product
+type ProductIdentificationHelper struct {
+	CPE           string       `json:"cpe,omitempty"`
+	Hashes        []Hash       `json:"hashes,omitempty"`
+	ModelNumbers  []string     `json:"model_numbers,omitempty"`
+	PURL          string       `json:"purl,omitempty"`
+	SBOMURLs      []string     `json:"sbom_urls,omitempty"`
+	SerialNumbers []string     `json:"serial_numbers,omitempty"`
+	SKUs          []string     `json:"skus,omitempty"`
+	XGenericURIs  []GenericURI `json:"x_generic_uris,omitempty"`
+}
+
+// Hash contains cryptographic hashes of a file belonging to a product
+type Hash struct {
+	FileHashes []FileHash `json:"file_hashes"`
+	Filename   string     `json:"filename"`
+}
+
+// FileHash is a single hash value together with its algorithm
+type FileHash struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+// GenericURI is a URI identifying a product in a given namespace
+type GenericURI struct {
+	Namespace string `json:"namespace"`
+	URI       string `json:"uri"`
+}
+
+// ProductGroup groups several product IDs under a single group ID
+type ProductGroup struct {
+	GroupID    string   `json:"group_id"`
+	ProductIDs []string `json:"product_ids"`
+	Summary    string   `json:"summary,omitempty"`
+}
+
+// Relationship defines a new product by combining two existing products
+type Relationship struct {
+	Category                  string          `json:"category"`
+	FullProductName           FullProductName `json:"full_product_name"`
+	ProductReference          string          `json:"product_reference"`
+	RelatesToProductReference string          `json:"relates_to_product_reference"`
+}
@@ -0,0 +1,168 @@
+package csaf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// relationshipPhrases describes how each relationship category joins the names of the two related products
+var relationshipPhrases = map[string]string{
+	"default_component_of":  "as a default component of",
+	"external_component_of": "as an external component of",
+	"installed_on":          "installed on",
+	"installed_with":        "installed with",
+	"optional_component_of": "as an optional component of",
+}
+
+// ProductResolver maps product and group IDs of a document to human-readable names
+type ProductResolver struct {
+	names  map[string]string
+	groups map[string]ProductGroup
+}
+
+// NewProductResolver indexes all products defined in the branches, full_product_names,
+// relationships and product_groups of a product tree. A nil tree yields a resolver
+// that returns all IDs unchanged.
+func NewProductResolver(pt *ProductTree) *ProductResolver {
+	r := &ProductResolver{
+		names:  make(map[string]string),
+		groups: make(map[string]ProductGroup),
+	}
+	if pt == nil {
+		return r
+	}
+
+	for _, branch := range pt.Branches {
+		r.addBranch(branch, nil)
+	}
+	for _, fpn := range pt.FullProductNames {
+		r.add(fpn.ProductID, fpn.Name)
+	}
+
+	// Relationships without a name are named after the products they relate, which
+	// may themselves be defined by other relationships, so resolve them in as many
+	// passes as needed for all references to be known
+	pending := pt.Relationships
+	for len(pending) > 0 {
+		var unresolved []Relationship
+		for _, rel := range pending {
+			if rel.FullProductName.Name == "" && (!r.IsDefined(rel.ProductReference) || !r.IsDefined(rel.RelatesToProductReference)) {
+				unresolved = append(unresolved, rel)
+				continue
+			}
+			r.add(rel.FullProductName.ProductID, r.relationshipName(rel))
+		}
+		if len(unresolved) == len(pending) {
+			// The remaining relationships refer to undefined products, use the IDs in their names
+			for _, rel := range unresolved {
+				r.add(rel.FullProductName.ProductID, r.relationshipName(rel))
+			}
+			break
+		}
+		pending = unresolved
+	}
+
+	for _, group := range pt.ProductGroups {
+		r.groups[group.GroupID] = group
+	}
+
+	return r
+}
+
+// addBranch records the product of a branch, deriving its name from the branch path if it has none
+func (r *ProductResolver) addBranch(branch Branch, path []string) {
+	path = append(path[:len(path):len(path)], branch.Name)
+	if branch.Product != nil {
+		name := branch.Product.Name
+		if name == "" {
+			name = strings.Join(path, " ")
+		}
+		r.add(branch.Product.ProductID, name)
+	}
+	for _, child := range branch.Branches {
+		r.addBranch(child, path)
+	}
+}
+
+// add records the name of a product, keeping the first definition if a product ID is defined more than once
+func (r *ProductResolver) add(productID, name string) {
+	if productID == "" {
+		return
+	}
+	if _, exists := r.names[productID]; !exists {
+		r.names[productID] = name
+	}
+}
+
+// relationshipName returns the name of the product defined by a relationship, composing
+// it from the names of the related products if the relationship does not name it
+func (r *ProductResolver) relationshipName(rel Relationship) string {
+	if rel.FullProductName.Name != "" {
+		return rel.FullProductName.Name
+	}
+	phrase, ok := relationshipPhrases[rel.Category]
+	if !ok {
+		phrase = strings.ReplaceAll(rel.Category, "_", " ")
+	}
+	return fmt.Sprintf("%s %s %s", r.Name(rel.ProductReference), phrase, r.Name(rel.RelatesToProductReference))
+}
+
+// Name returns the human-readable name of a product, or the product ID itself if it is not defined
+func (r *ProductResolver) Name(productID string) string {
+	if name, ok := r.names[productID]; ok && name != "" {
+		return name
+	}
+	return productID
+}
+
+// Label returns the name of a product followed by its ID, or just the ID if the product has no name
+func (r *ProductResolver) Label(productID string) string {
+	name := r.Name(productID)
+	if name == productID {
+		return productID
+	}
+	return fmt.Sprintf("%s [%s]", name, productID)
+}
+
+// IsDefined checks if a product ID is defined anywhere in the product tree
+func (r *ProductResolver) IsDefined(productID string) bool {
+	_, ok := r.names[productID]
+	return ok
+}
+
+// GroupProducts returns the product IDs that belong to a product group
+func (r *ProductResolver) GroupProducts(groupID string) []string {
+	return r.groups[groupID].ProductIDs
+}
+
+// Expand returns the product IDs together with the members of all given product groups,
+// without duplicates and in the order they were first referenced
+func (r *ProductResolver) Expand(productIDs, groupIDs []string) []string {
+	seen := make(map[string]bool)
+	var expanded []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			expanded = append(expanded, id)
+		}
+	}
+
+	for _, id := range productIDs {
+		add(id)
+	}
+	for _, groupID := range groupIDs {
+		for _, id := range r.GroupProducts(groupID) {
+			add(id)
+		}
+	}
+	return expanded
+}
+
+// Names resolves a list of product IDs to their human-readable names
+func (r *ProductResolver) Names(productIDs []string) []string {
+	names := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		names[i] = r.Name(id)
+	}
+	return names
+}
@@ -0,0 +1,304 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// cvssMetric describes a metric of a CVSS vector and the JSON property that mirrors it
+type cvssMetric struct {
+	key      string
+	property string
+	values   map[string]string
+}
+
+var (
+	cvss3BaseValues = map[string]map[string]string{
+		"AV": {"N": "NETWORK", "A": "ADJACENT_NETWORK", "L": "LOCAL", "P": "PHYSICAL"},
+		"AC": {"L": "LOW", "H": "HIGH"},
+		"PR": {"N": "NONE", "L": "LOW", "H": "HIGH"},
+		"UI": {"N": "NONE", "R": "REQUIRED"},
+		"S":  {"U": "UNCHANGED", "C": "CHANGED"},
+		"C":  {"H": "HIGH", "L": "LOW", "N": "NONE"},
+	}
+	cvss3Requirement = map[string]string{"X": "NOT_DEFINED", "L": "LOW", "M": "MEDIUM", "H": "HIGH"}
+
+	cvss3Metrics = []cvssMetric{
+		{"AV", "attackVector", cvss3BaseValues["AV"]},
+		{"AC", "attackComplexity", cvss3BaseValues["AC"]},
+		{"PR", "privilegesRequired", cvss3BaseValues["PR"]},
+		{"UI", "userInteraction", cvss3BaseValues["UI"]},
+		{"S", "scope", cvss3BaseValues["S"]},
+		{"C", "confidentialityImpact", cvss3BaseValues["C"]},
+		{"I", "integrityImpact", cvss3BaseValues["C"]},
+		{"A", "availabilityImpact", cvss3BaseValues["C"]},
+		{"E", "exploitCodeMaturity", map[string]string{"X": "NOT_DEFINED", "U": "UNPROVEN", "P": "PROOF_OF_CONCEPT", "F": "FUNCTIONAL", "H": "HIGH"}},
+		{"RL", "remediationLevel", map[string]string{"X": "NOT_DEFINED", "O": "OFFICIAL_FIX", "T": "TEMPORARY_FIX", "W": "WORKAROUND", "U": "UNAVAILABLE"}},
+		{"RC", "reportConfidence", map[string]string{"X": "NOT_DEFINED", "U": "UNKNOWN", "R": "REASONABLE", "C": "CONFIRMED"}},
+		{"CR", "confidentialityRequirement", cvss3Requirement},
+		{"IR", "integrityRequirement", cvss3Requirement},
+		{"AR", "availabilityRequirement", cvss3Requirement},
+		{"MAV", "modifiedAttackVector", withNotDefined(cvss3BaseValues["AV"], "X")},
+		{"MAC", "modifiedAttackComplexity", withNotDefined(cvss3BaseValues["AC"], "X")},
+		{"MPR", "modifiedPrivilegesRequired", withNotDefined(cvss3BaseValues["PR"], "X")},
+		{"MUI", "modifiedUserInteraction", withNotDefined(cvss3BaseValues["UI"], "X")},
+		{"MS", "modifiedScope", withNotDefined(cvss3BaseValues["S"], "X")},
+		{"MC", "modifiedConfidentialityImpact", withNotDefined(cvss3BaseValues["C"], "X")},
+		{"MI", "modifiedIntegrityImpact", withNotDefined(cvss3BaseValues["C"], "X")},
+		{"MA", "modifiedAvailabilityImpact", withNotDefined(cvss3BaseValues["C"], "X")},
+	}
+
+	cvss2Requirement = map[string]string{"L": "LOW", "M": "MEDIUM", "H": "HIGH", "ND": "NOT_DEFINED"}
+	cvss2Impact      = map[string]string{"N": "NONE", "P": "PARTIAL", "C": "COMPLETE"}
+
+	cvss2Metrics = []cvssMetric{
+		{"AV", "accessVector", map[string]string{"L": "LOCAL", "A": "ADJACENT_NETWORK", "N": "NETWORK"}},
+		{"AC", "accessComplexity", map[string]string{"H": "HIGH", "M": "MEDIUM", "L": "LOW"}},
+		{"Au", "authentication", map[string]string{"M": "MULTIPLE", "S": "SINGLE", "N": "NONE"}},
+		{"C", "confidentialityImpact", cvss2Impact},
+		{"I", "integrityImpact", cvss2Impact},
+		{"A", "availabilityImpact", cvss2Impact},
+		{"E", "exploitability", map[string]string{"U": "UNPROVEN", "POC": "PROOF_OF_CONCEPT", "F": "FUNCTIONAL", "H": "HIGH", "ND": "NOT_DEFINED"}},
+		{"RL", "remediationLevel", map[string]string{"OF": "OFFICIAL_FIX", "TF": "TEMPORARY_FIX", "W": "WORKAROUND", "U": "UNAVAILABLE", "ND": "NOT_DEFINED"}},
+		{"RC", "reportConfidence", map[string]string{"UC": "UNCONFIRMED", "UR": "UNCORROBORATED", "C": "CONFIRMED", "ND": "NOT_DEFINED"}},
+		{"CDP", "collateralDamagePotential", map[string]string{"N": "NONE", "L": "LOW", "LM": "LOW_MEDIUM", "MH": "MEDIUM_HIGH", "H": "HIGH", "ND": "NOT_DEFINED"}},
+		{"TD", "targetDistribution", map[string]string{"N": "NONE", "L": "LOW", "M": "MEDIUM", "H": "HIGH", "ND": "NOT_DEFINED"}},
+		{"CR", "confidentialityRequirement", cvss2Requirement},
+		{"IR", "integrityRequirement", cvss2Requirement},
+		{"AR", "availabilityRequirement", cvss2Requirement},
+	}
+)
+
+// withNotDefined returns a copy of metric values extended by the "not defined" value
+func withNotDefined(values map[string]string, key string) map[string]string {
+	extended := map[string]string{key: "NOT_DEFINED"}
+	for k, v := range values {
+		extended[k] = v
+	}
+	return extended
+}
+
+// parseCVSSVector splits a CVSS vector string into its metrics. The version prefix of
+// CVSS v3 vectors (e.g. "CVSS:3.1/") is returned separately.
+func parseCVSSVector(vector string) (string, map[string]string, error) {
+	parts := strings.Split(vector, "/")
+	prefix := ""
+	if strings.HasPrefix(parts[0], "CVSS:") {
+		prefix = strings.TrimPrefix(parts[0], "CVSS:")
+		parts = parts[1:]
+	}
+
+	metrics := make(map[string]string)
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return "", nil, fmt.Errorf("invalid metric '%s' in vector", part)
+		}
+		metrics[key] = value
+	}
+	return prefix, metrics, nil
+}
+
+// cvssScores holds the scores computed from a CVSS vector
+type cvssScores struct {
+	base          float64
+	temporal      float64
+	environmental float64
+}
+
+// cvss3Weights contains the numerical values of the CVSS v3 metrics
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"E":  {"X": 1, "U": 0.91, "P": 0.94, "F": 0.97, "H": 1},
+	"RL": {"X": 1, "O": 0.95, "T": 0.96, "W": 0.97, "U": 1},
+	"RC": {"X": 1, "U": 0.92, "R": 0.96, "C": 1},
+	"CR": {"X": 1, "L": 0.5, "M": 1, "H": 1.5},
+}
+
+// cvss3PrivilegesRequired returns the weight of the privileges required metric, which depends on the scope
+func cvss3PrivilegesRequired(value string, scopeChanged bool) float64 {
+	switch value {
+	case "N":
+		return 0.85
+	case "L":
+		if scopeChanged {
+			return 0.68
+		}
+		return 0.62
+	default:
+		if scopeChanged {
+			return 0.5
+		}
+		return 0.27
+	}
+}
+
+// cvss3Roundup rounds up to one decimal as defined in the respective CVSS v3 specification
+func cvss3Roundup(value float64, version string) float64 {
+	if version == "3.0" {
+		return math.Ceil(value*10) / 10
+	}
+	// CVSS v3.1 avoids floating point errors by rounding on integers
+	intInput := int64(math.Round(value * 100000))
+	if intInput%10000 == 0 {
+		return float64(intInput) / 100000
+	}
+	return float64(intInput/10000+1) / 10
+}
+
+// computeCVSS3 computes the base, temporal and environmental scores of a CVSS v3 vector
+func computeCVSS3(version string, m map[string]string) (cvssScores, error) {
+	for _, key := range []string{"AV", "AC", "PR", "UI", "S", "C", "I", "A"} {
+		if _, ok := m[key]; !ok {
+			return cvssScores{}, fmt.Errorf("vector is missing base metric %s", key)
+		}
+	}
+
+	get := func(key, fallback string) string {
+		if v, ok := m[key]; ok && v != "X" {
+			return v
+		}
+		return fallback
+	}
+	weight := func(table, value string) float64 {
+		return cvss3Weights[table][value]
+	}
+
+	// Base score
+	scopeChanged := m["S"] == "C"
+	iss := 1 - (1-weight("C", m["C"]))*(1-weight("C", m["I"]))*(1-weight("C", m["A"]))
+	var impact float64
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	exploitability := 8.22 * weight("AV", m["AV"]) * weight("AC", m["AC"]) *
+		cvss3PrivilegesRequired(m["PR"], scopeChanged) * weight("UI", m["UI"])
+
+	var scores cvssScores
+	switch {
+	case impact <= 0:
+		scores.base = 0
+	case scopeChanged:
+		scores.base = cvss3Roundup(math.Min(1.08*(impact+exploitability), 10), version)
+	default:
+		scores.base = cvss3Roundup(math.Min(impact+exploitability, 10), version)
+	}
+
+	// Temporal score
+	temporalFactor := weight("E", get("E", "X")) * weight("RL", get("RL", "X")) * weight("RC", get("RC", "X"))
+	scores.temporal = cvss3Roundup(scores.base*temporalFactor, version)
+
+	// Environmental score, where modified metrics default to their base metrics
+	modifiedScopeChanged := get("MS", m["S"]) == "C"
+	miss := math.Min(1-
+		(1-weight("CR", get("CR", "X"))*weight("C", get("MC", m["C"])))*
+			(1-weight("CR", get("IR", "X"))*weight("C", get("MI", m["I"])))*
+			(1-weight("CR", get("AR", "X"))*weight("C", get("MA", m["A"]))), 0.915)
+
+	var modifiedImpact float64
+	switch {
+	case !modifiedScopeChanged:
+		modifiedImpact = 6.42 * miss
+	case version == "3.0":
+		modifiedImpact = 7.52*(miss-0.029) - 3.25*math.Pow(miss-0.02, 15)
+	default:
+		modifiedImpact = 7.52*(miss-0.029) - 3.25*math.Pow(miss*0.9731-0.02, 13)
+	}
+	modifiedExploitability := 8.22 * weight("AV", get("MAV", m["AV"])) * weight("AC", get("MAC", m["AC"])) *
+		cvss3PrivilegesRequired(get("MPR", m["PR"]), modifiedScopeChanged) * weight("UI", get("MUI", m["UI"]))
+
+	switch {
+	case modifiedImpact <= 0:
+		scores.environmental = 0
+	case modifiedScopeChanged:
+		scores.environmental = cvss3Roundup(
+			cvss3Roundup(math.Min(1.08*(modifiedImpact+modifiedExploitability), 10), version)*temporalFactor, version)
+	default:
+		scores.environmental = cvss3Roundup(
+			cvss3Roundup(math.Min(modifiedImpact+modifiedExploitability, 10), version)*temporalFactor, version)
+	}
+
+	return scores, nil
+}
+
+// cvss3Severity returns the qualitative severity rating of a CVSS v3 score
+func cvss3Severity(score float64) string {
+	switch {
+	case score == 0:
+		return "NONE"
+	case score < 4.0:
+		return "LOW"
+	case score < 7.0:
+		return "MEDIUM"
+	case score < 9.0:
+		return "HIGH"
+	default:
+		return "CRITICAL"
+	}
+}
+
+// cvss2Weights contains the numerical values of the CVSS v2 metrics
+var cvss2Weights = map[string]map[string]float64{
+	"AV":  {"L": 0.395, "A": 0.646, "N": 1.0},
+	"AC":  {"H": 0.35, "M": 0.61, "L": 0.71},
+	"Au":  {"M": 0.45, "S": 0.56, "N": 0.704},
+	"C":   {"N": 0, "P": 0.275, "C": 0.660},
+	"E":   {"U": 0.85, "POC": 0.9, "F": 0.95, "H": 1, "ND": 1},
+	"RL":  {"OF": 0.87, "TF": 0.90, "W": 0.95, "U": 1, "ND": 1},
+	"RC":  {"UC": 0.90, "UR": 0.95, "C": 1, "ND": 1},
+	"CDP": {"N": 0, "L": 0.1, "LM": 0.3, "MH": 0.4, "H": 0.5, "ND": 0},
+	"TD":  {"N": 0, "L": 0.25, "M": 0.75, "H": 1, "ND": 1},
+	"CR":  {"L": 0.5, "M": 1, "H": 1.51, "ND": 1},
+}
+
+// computeCVSS2 computes the base, temporal and environmental scores of a CVSS v2 vector
+func computeCVSS2(m map[string]string) (cvssScores, error) {
+	for _, key := range []string{"AV", "AC", "Au", "C", "I", "A"} {
+		if _, ok := m[key]; !ok {
+			return cvssScores{}, fmt.Errorf("vector is missing base metric %s", key)
+		}
+	}
+
+	get := func(key string) string {
+		if v, ok := m[key]; ok {
+			return v
+		}
+		return "ND"
+	}
+	weight := func(table, value string) float64 {
+		return cvss2Weights[table][value]
+	}
+	round := func(value float64) float64 {
+		return math.Round(value*10) / 10
+	}
+	baseScore := func(impact float64) float64 {
+		f := 1.176
+		if impact == 0 {
+			f = 0
+		}
+		exploitability := 20 * weight("AV", m["AV"]) * weight("AC", m["AC"]) * weight("Au", m["Au"])
+		return round((0.6*impact + 0.4*exploitability - 1.5) * f)
+	}
+
+	c, i, a := weight("C", m["C"]), weight("C", m["I"]), weight("C", m["A"])
+	temporalFactor := weight("E", get("E")) * weight("RL", get("RL")) * weight("RC", get("RC"))
+
+	var scores cvssScores
+	scores.base = baseScore(10.41 * (1 - (1-c)*(1-i)*(1-a)))
+	scores.temporal = round(scores.base * temporalFactor)
+
+	adjustedImpact := math.Min(10, 10.41*(1-
+		(1-c*weight("CR", get("CR")))*
+			(1-i*weight("CR", get("IR")))*
+			(1-a*weight("CR", get("AR")))))
+	adjustedTemporal := round(baseScore(adjustedImpact) * temporalFactor)
+	scores.environmental = round((adjustedTemporal + (10-adjustedTemporal)*weight("CDP", get("CDP"))) * weight("TD", get("TD")))
+
+	return scores, nil
+}
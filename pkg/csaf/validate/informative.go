@@ -0,0 +1,320 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// The informative tests of section 6.3 of the CSAF 2.0 specification. Tests 6.3.6 and
+// 6.3.7 are run offline: instead of resolving the URLs they report URLs that are not
+// valid https:// URLs. Test 6.3.8 only reports findings when a spell checker is set.
+func init() {
+	registerTests(
+		Test{"6.3.1", "Use of CVSS v2 as the only Scoring System", testOnlyCVSSv2},
+		Test{"6.3.2", "Use of CVSS v3.0", testCVSSv30},
+		Test{"6.3.3", "Missing CVE", testMissingCVE},
+		Test{"6.3.4", "Missing CWE", testMissingCWE},
+		Test{"6.3.5", "Use of Short Hash", testShortHash},
+		Test{"6.3.6", "Use of non-self referencing URLs Failing to Resolve", testReferenceURLs(false)},
+		Test{"6.3.7", "Use of self referencing URLs Failing to Resolve", testReferenceURLs(true)},
+		Test{"6.3.8", "Spell check", testSpelling},
+		Test{"6.3.9", "Branch Categories", testBranchCategories},
+		Test{"6.3.10", "Usage of Product Version Range", testProductVersionRange},
+		Test{"6.3.11", "Usage of V as Version Indicator", testVersionIndicator},
+	)
+}
+
+func testOnlyCVSSv2(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for s, score := range vuln.Scores {
+			if score.CVSSv2 != nil && score.CVSSv3 == nil {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "scores", s),
+					"score only uses CVSS v2"))
+			}
+		}
+	}
+	return violations
+}
+
+func testCVSSv30(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for s, score := range vuln.Scores {
+			if score.CVSSv3 != nil && score.CVSSv3.Version == "3.0" {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "scores", s, "cvss_v3", "version"),
+					"score uses CVSS v3.0 instead of v3.1"))
+			}
+		}
+	}
+	return violations
+}
+
+func testMissingCVE(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		if vuln.CVE == "" {
+			violations = append(violations, violation(pointer("vulnerabilities", v), "vulnerability has no CVE ID"))
+		}
+	}
+	return violations
+}
+
+func testMissingCWE(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		if vuln.CWE == nil {
+			violations = append(violations, violation(pointer("vulnerabilities", v), "vulnerability has no CWE"))
+		}
+	}
+	return violations
+}
+
+func testShortHash(doc *csaf.Document) []Violation {
+	var violations []Violation
+	productHelpers(doc, func(helper *csaf.ProductIdentificationHelper, path string) {
+		for h, hash := range helper.Hashes {
+			for i, fh := range hash.FileHashes {
+				if len(fh.Value) < 64 {
+					violations = append(violations, violation(
+						fmt.Sprintf("%s/hashes/%d/file_hashes/%d/value", path, h, i),
+						"%s hash of %s is shorter than 64 characters", fh.Algorithm, hash.Filename))
+				}
+			}
+		}
+	})
+	return violations
+}
+
+// testReferenceURLs returns a test that reports self or non-self references whose URL is not a valid https:// URL
+func testReferenceURLs(self bool) func(doc *csaf.Document) []Violation {
+	return func(doc *csaf.Document) []Violation {
+		var violations []Violation
+		check := func(refs []csaf.Reference, tokens ...any) {
+			for i, r := range refs {
+				if (r.Category == "self") != self {
+					continue
+				}
+				u, err := url.Parse(r.URL)
+				if err != nil || u.Scheme != "https" || u.Host == "" {
+					violations = append(violations, violation(pointer(append(tokens, i, "url")...),
+						"%s is not a valid https:// URL", r.URL))
+				}
+			}
+		}
+
+		check(doc.Document.References, "document", "references")
+		for v, vuln := range doc.Vulnerabilities {
+			check(vuln.References, "vulnerabilities", v, "references")
+		}
+		return violations
+	}
+}
+
+// SpellChecker checks text for spelling mistakes
+type SpellChecker interface {
+	// Check returns the misspelled words in a text written in the given language
+	Check(lang, text string) ([]string, error)
+}
+
+// spellChecker is used by test 6.3.8, which is skipped if it is nil
+var spellChecker SpellChecker
+
+// SetSpellChecker sets the spell checker used by test 6.3.8
+func SetSpellChecker(checker SpellChecker) {
+	spellChecker = checker
+}
+
+// CommandSpellChecker runs an external command such as "hunspell -l" that reads text on
+// standard input and prints one misspelled word per line. The placeholder {lang} in the
+// arguments is replaced with the document language.
+type CommandSpellChecker struct {
+	Command []string
+}
+
+// NewCommandSpellChecker creates a spell checker from a command line
+func NewCommandSpellChecker(command string) (*CommandSpellChecker, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty spell checker command")
+	}
+	return &CommandSpellChecker{Command: fields}, nil
+}
+
+// Check runs the spell checker command on the text
+func (c *CommandSpellChecker) Check(lang, text string) ([]string, error) {
+	if lang == "" {
+		lang = "en"
+	}
+	args := make([]string, len(c.Command)-1)
+	for i, arg := range c.Command[1:] {
+		args[i] = strings.ReplaceAll(arg, "{lang}", lang)
+	}
+
+	cmd := exec.Command(c.Command[0], args...)
+	cmd.Stdin = strings.NewReader(text)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run spell checker %s: %w", c.Command[0], err)
+	}
+
+	var words []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word != "" && !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// textFields returns the prose fields of the document, keyed by their JSON pointer
+func textFields(doc *csaf.Document) [][2]string {
+	var fields [][2]string
+	add := func(text string, tokens ...any) {
+		if text != "" {
+			fields = append(fields, [2]string{pointer(tokens...), text})
+		}
+	}
+	addNotes := func(notes []csaf.Note, tokens ...any) {
+		for i, n := range notes {
+			add(n.Title, append(tokens, i, "title")...)
+			add(n.Text, append(tokens, i, "text")...)
+		}
+	}
+
+	d := doc.Document
+	add(d.Title, "document", "title")
+	addNotes(d.Notes, "document", "notes")
+	for i, r := range d.References {
+		add(r.Summary, "document", "references", i, "summary")
+	}
+	for i, r := range d.Tracking.RevisionHistory {
+		add(r.Summary, "document", "tracking", "revision_history", i, "summary")
+	}
+
+	for v, vuln := range doc.Vulnerabilities {
+		add(vuln.Title, "vulnerabilities", v, "title")
+		addNotes(vuln.Notes, "vulnerabilities", v, "notes")
+		for i, r := range vuln.References {
+			add(r.Summary, "vulnerabilities", v, "references", i, "summary")
+		}
+		for i, r := range vuln.Remediations {
+			add(r.Details, "vulnerabilities", v, "remediations", i, "details")
+		}
+		for i, t := range vuln.Threats {
+			add(t.Details, "vulnerabilities", v, "threats", i, "details")
+		}
+		for i, inv := range vuln.Involvements {
+			add(inv.Summary, "vulnerabilities", v, "involvements", i, "summary")
+		}
+	}
+	return fields
+}
+
+func testSpelling(doc *csaf.Document) []Violation {
+	if spellChecker == nil {
+		return nil
+	}
+
+	var violations []Violation
+	for _, field := range textFields(doc) {
+		words, err := spellChecker.Check(doc.Document.Lang, field[1])
+		if err != nil {
+			return append(violations, violation(field[0], "spell check failed: %v", err))
+		}
+		if len(words) > 0 {
+			violations = append(violations, violation(field[0], "possible spelling mistakes: %s", strings.Join(words, ", ")))
+		}
+	}
+	return violations
+}
+
+// requiredBranchCategories are the branch categories expected in order on the path to every product
+var requiredBranchCategories = []string{"vendor", "product_name", "product_version"}
+
+func testBranchCategories(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	var violations []Violation
+	var walk func(branches []csaf.Branch, path string, categories []string)
+	walk = func(branches []csaf.Branch, path string, categories []string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			branchCategories := append(categories[:len(categories):len(categories)], b.Category)
+			if b.Product != nil {
+				next := 0
+				for _, c := range branchCategories {
+					if next < len(requiredBranchCategories) && c == requiredBranchCategories[next] {
+						next++
+					}
+				}
+				if next < len(requiredBranchCategories) {
+					violations = append(violations, violation(branchPath+"/product",
+						"product %s is not placed below branches of the categories %s",
+						b.Product.ProductID, strings.Join(requiredBranchCategories, " -> ")))
+				}
+			}
+			walk(b.Branches, branchPath+"/branches", branchCategories)
+		}
+	}
+	walk(doc.ProductTree.Branches, "/product_tree/branches", nil)
+	return violations
+}
+
+func testProductVersionRange(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	var violations []Violation
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Category == "product_version_range" {
+				violations = append(violations, violation(branchPath+"/category",
+					"branch %s uses the product_version_range category", b.Name))
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(doc.ProductTree.Branches, "/product_tree/branches")
+	return violations
+}
+
+// versionIndicatorPattern matches versions that are prefixed with a "v" or "V"
+var versionIndicatorPattern = regexp.MustCompile(`^[vV][0-9].*$`)
+
+func testVersionIndicator(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	var violations []Violation
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Category == "product_version" && versionIndicatorPattern.MatchString(b.Name) {
+				violations = append(violations, violation(branchPath+"/name",
+					"product version %s is prefixed with a version indicator", b.Name))
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(doc.ProductTree.Branches, "/product_tree/branches")
+	return violations
+}
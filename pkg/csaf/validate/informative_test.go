@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+// misspellings is a spell checker that reports the words of a fixed list
+type misspellings []string
+
+func (m misspellings) Check(lang, text string) ([]string, error) {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		for _, misspelled := range m {
+			if word == misspelled {
+				words = append(words, word)
+			}
+		}
+	}
+	return words, nil
+}
+
+var informativeCases = []testCase{
+	{
+		id:       "6.3.1",
+		name:     "CVSS v2 together with v3",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v2": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:P/I:P/A:P", "baseScore": 7.5}, "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+	},
+	{
+		id:       "6.3.1",
+		name:     "only CVSS v2",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v2": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:P/I:P/A:P", "baseScore": 7.5}}]}]}`,
+		want:     []string{"/vulnerabilities/0/scores/0"},
+	},
+	{
+		id:       "6.3.2",
+		name:     "CVSS v3.1",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+	},
+	{
+		id:       "6.3.2",
+		name:     "CVSS v3.0",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.0", "vectorString": "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+		want:     []string{"/vulnerabilities/0/scores/0/cvss_v3/version"},
+	},
+	{
+		id:       "6.3.3",
+		name:     "CVE",
+		document: `{"vulnerabilities": [{"cve": "CVE-2024-0001"}]}`,
+	},
+	{
+		id:       "6.3.3",
+		name:     "missing CVE",
+		document: `{"vulnerabilities": [{"cve": "CVE-2024-0001"}, {"ids": [{"system_name": "Tracker", "text": "BUG-1"}]}]}`,
+		want:     []string{"/vulnerabilities/1"},
+	},
+	{
+		id:       "6.3.4",
+		name:     "CWE",
+		document: `{"vulnerabilities": [{"cwe": {"id": "CWE-79", "name": "Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting')"}}]}`,
+	},
+	{
+		id:       "6.3.4",
+		name:     "missing CWE",
+		document: `{"vulnerabilities": [{"cve": "CVE-2024-0001"}]}`,
+		want:     []string{"/vulnerabilities/0"},
+	},
+	{
+		id:       "6.3.5",
+		name:     "full hash",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "sha256", "value": "026a37919b182ef7c63791e82c9645e2f897a3f0b73c7a6028c7febf62e93838"}]}]}}]}}`,
+	},
+	{
+		id:       "6.3.5",
+		name:     "short hash",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "sha256", "value": "026a37919b182ef7c63791e82c9645e2f897a3f0b73c7a6028c7febf62e93838"}, {"algorithm": "md5", "value": "6f7c4e8bd4a4b04e4e2e8b5d4c3d1f0a"}]}]}}]}}`,
+		want:     []string{"/product_tree/full_product_names/0/product_identification_helper/hashes/0/file_hashes/1/value"},
+	},
+	{
+		id:       "6.3.6",
+		name:     "https references",
+		document: `{"document": {"references": [{"category": "self", "summary": "Advisory", "url": "ftp://example.com/advisory.json"}, {"category": "external", "summary": "Blog", "url": "https://example.com/blog"}]}}`,
+	},
+	{
+		id:       "6.3.6",
+		name:     "plain http reference",
+		document: `{"document": {"references": [{"category": "external", "summary": "Blog", "url": "https://example.com/blog"}]}, "vulnerabilities": [{"references": [{"summary": "Details", "url": "http://example.com/details"}]}]}`,
+		want:     []string{"/vulnerabilities/0/references/0/url"},
+	},
+	{
+		id:       "6.3.7",
+		name:     "https self reference",
+		document: `{"document": {"references": [{"category": "self", "summary": "Advisory", "url": "https://example.com/advisory.json"}, {"category": "external", "summary": "Blog", "url": "ftp://example.com/blog"}]}}`,
+	},
+	{
+		id:       "6.3.7",
+		name:     "self reference without host",
+		document: `{"document": {"references": [{"category": "external", "summary": "Blog", "url": "https://example.com/blog"}, {"category": "self", "summary": "Advisory", "url": "https:///advisory.json"}]}}`,
+		want:     []string{"/document/references/1/url"},
+	},
+	{
+		id:       "6.3.8",
+		name:     "correct spelling",
+		document: `{"document": {"title": "The advisory", "notes": [{"category": "summary", "text": "A summary"}]}}`,
+	},
+	{
+		id:       "6.3.8",
+		name:     "spelling mistakes",
+		document: `{"document": {"title": "The advisory", "notes": [{"category": "summary", "title": "Teh summary", "text": "A summary"}]}, "vulnerabilities": [{"remediations": [{"category": "vendor_fix", "details": "Updaet now", "product_ids": ["CSAFPID-1"]}]}]}`,
+		want:     []string{"/document/notes/0/title", "/vulnerabilities/0/remediations/0/details"},
+	},
+	{
+		id:       "6.3.9",
+		name:     "vendor, product name and version branches",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_name", "name": "A", "branches": [{"category": "product_family", "name": "F", "branches": [{"category": "product_version", "name": "1.0", "product": {"name": "A 1.0", "product_id": "CSAFPID-1"}}]}]}]}]}}`,
+	},
+	{
+		id:       "6.3.9",
+		name:     "product without version branch",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_name", "name": "A", "product": {"name": "A", "product_id": "CSAFPID-1"}}]}]}}`,
+		want:     []string{"/product_tree/branches/0/branches/0/product"},
+	},
+	{
+		id:       "6.3.10",
+		name:     "product versions",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version", "name": "4.2"}]}]}}`,
+	},
+	{
+		id:       "6.3.10",
+		name:     "product version range",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version", "name": "4.2"}, {"category": "product_version_range", "name": "vers:generic/<4.2"}]}]}}`,
+		want:     []string{"/product_tree/branches/0/branches/1/category"},
+	},
+	{
+		id:       "6.3.11",
+		name:     "version without indicator",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version", "name": "4.2"}, {"category": "product_name", "name": "v2 Server"}]}]}}`,
+	},
+	{
+		id:       "6.3.11",
+		name:     "version with indicator",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version", "name": "V4.2"}]}]}}`,
+		want:     []string{"/product_tree/branches/0/branches/0/name"},
+	},
+}
+
+func TestInformativeTests(t *testing.T) {
+	SetSpellChecker(misspellings{"Teh", "Updaet"})
+	t.Cleanup(func() { SetSpellChecker(nil) })
+
+	assertCovered(t, testsWithPrefix(profileSections[ProfileInformative]), informativeCases)
+	runTestCases(t, informativeCases)
+}
+
+func TestSpellingWithoutSpellChecker(t *testing.T) {
+	runTestCases(t, []testCase{{
+		id:       "6.3.8",
+		name:     "skipped without spell checker",
+		document: `{"document": {"title": "Teh advisory"}}`,
+	}})
+}
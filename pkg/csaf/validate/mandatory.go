@@ -0,0 +1,975 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// The mandatory tests of section 6.1 of the CSAF 2.0 specification. Test 6.1.11
+// (CWE) needs the CWE catalog to check names against IDs and is not implemented.
+func init() {
+	registerTests(
+		Test{"6.1.1", "Missing Definition of Product ID", testMissingProductDefinition},
+		Test{"6.1.2", "Multiple Definition of Product ID", testMultipleProductDefinition},
+		Test{"6.1.3", "Circular Definition of Product ID", testCircularProductDefinition},
+		Test{"6.1.4", "Missing Definition of Product Group ID", testMissingGroupDefinition},
+		Test{"6.1.5", "Multiple Definition of Product Group ID", testMultipleGroupDefinition},
+		Test{"6.1.6", "Contradicting Product Status", testContradictingProductStatus},
+		Test{"6.1.7", "Multiple Scores with same Version per Product", testMultipleScores},
+		Test{"6.1.8", "Invalid CVSS", testInvalidCVSS},
+		Test{"6.1.9", "Invalid CVSS computation", testInvalidCVSSComputation},
+		Test{"6.1.10", "Inconsistent CVSS", testInconsistentCVSS},
+		Test{"6.1.12", "Language", testLanguage},
+		Test{"6.1.13", "PURL", testPURL},
+		Test{"6.1.14", "Sorted Revision History", testSortedRevisionHistory},
+		Test{"6.1.15", "Translator", testTranslator},
+		Test{"6.1.16", "Latest Document Version", testLatestDocumentVersion},
+		Test{"6.1.17", "Document Status Draft", testDocumentStatusDraft},
+		Test{"6.1.18", "Released Revision in Revision History", testReleasedRevision},
+		Test{"6.1.19", "Revision History Entries for Pre-release Versions", testPreReleaseRevisions},
+		Test{"6.1.20", "Non-draft Document Version", testNonDraftVersion},
+		Test{"6.1.21", "Missing Item in Revision History", testMissingRevision},
+		Test{"6.1.22", "Multiple Definition in Revision History", testMultipleRevisions},
+		Test{"6.1.23", "Multiple Use of Same CVE", testMultipleCVE},
+		Test{"6.1.24", "Multiple Definition in Involvements", testMultipleInvolvements},
+		Test{"6.1.25", "Multiple Use of Same Hash Algorithm", testMultipleHashAlgorithms},
+		Test{"6.1.26", "Prohibited Document Category Name", testProhibitedCategory},
+		Test{"6.1.27.1", "Document Notes", testProfileDocumentNotes},
+		Test{"6.1.27.2", "Document References", testProfileDocumentReferences},
+		Test{"6.1.27.3", "Vulnerabilities", testProfileNoVulnerabilities},
+		Test{"6.1.27.4", "Product Tree", testProfileProductTree},
+		Test{"6.1.27.5", "Vulnerability Notes", testProfileVulnerabilityNotes},
+		Test{"6.1.27.6", "Product Status", testProfileProductStatus},
+		Test{"6.1.27.7", "VEX Product Status", testProfileVEXProductStatus},
+		Test{"6.1.27.8", "Vulnerability ID", testProfileVulnerabilityID},
+		Test{"6.1.27.9", "Impact Statement", testProfileImpactStatement},
+		Test{"6.1.27.10", "Action Statement", testProfileActionStatement},
+		Test{"6.1.27.11", "Vulnerabilities", testProfileVulnerabilities},
+		Test{"6.1.28", "Translation", testTranslation},
+		Test{"6.1.29", "Remediation without Product Reference", testRemediationWithoutProduct},
+		Test{"6.1.30", "Mixed Integer and Semantic Versioning", testMixedVersioning},
+		Test{"6.1.31", "Version Range in Product Version", testVersionRange},
+		Test{"6.1.32", "Flag without Product Reference", testFlagWithoutProduct},
+		Test{"6.1.33", "Multiple Flags with VEX Justification Codes per Product", testMultipleFlags},
+	)
+}
+
+// violation creates a violation at the given JSON pointer with a formatted message
+func violation(path, format string, args ...any) Violation {
+	return Violation{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+func testMissingProductDefinition(doc *csaf.Document) []Violation {
+	defined := make(map[string]bool)
+	for _, def := range productDefinitions(doc) {
+		defined[def.id] = true
+	}
+
+	var violations []Violation
+	for _, ref := range productReferences(doc) {
+		if !defined[ref.id] {
+			violations = append(violations, violation(ref.path, "product ID %s is not defined in the product tree", ref.id))
+		}
+	}
+	return violations
+}
+
+func testMultipleProductDefinition(doc *csaf.Document) []Violation {
+	first := make(map[string]string)
+	var violations []Violation
+	for _, def := range productDefinitions(doc) {
+		if path, ok := first[def.id]; ok {
+			violations = append(violations, violation(def.path, "product ID %s is already defined at %s", def.id, path))
+			continue
+		}
+		first[def.id] = def.path
+	}
+	return violations
+}
+
+func testCircularProductDefinition(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	// Each product defined by a relationship depends on the two products it relates
+	dependsOn := make(map[string][]string)
+	for _, rel := range doc.ProductTree.Relationships {
+		id := rel.FullProductName.ProductID
+		dependsOn[id] = append(dependsOn[id], rel.ProductReference, rel.RelatesToProductReference)
+	}
+
+	var reaches func(from, target string, visited map[string]bool) bool
+	reaches = func(from, target string, visited map[string]bool) bool {
+		for _, dep := range dependsOn[from] {
+			if dep == target {
+				return true
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				if reaches(dep, target, visited) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var violations []Violation
+	for i, rel := range doc.ProductTree.Relationships {
+		id := rel.FullProductName.ProductID
+		if reaches(id, id, make(map[string]bool)) {
+			violations = append(violations, violation(
+				pointer("product_tree", "relationships", i, "full_product_name", "product_id"),
+				"product ID %s is defined in terms of itself", id))
+		}
+	}
+	return violations
+}
+
+func testMissingGroupDefinition(doc *csaf.Document) []Violation {
+	defined := make(map[string]bool)
+	if doc.ProductTree != nil {
+		for _, g := range doc.ProductTree.ProductGroups {
+			defined[g.GroupID] = true
+		}
+	}
+
+	var violations []Violation
+	for _, ref := range groupReferences(doc) {
+		if !defined[ref.id] {
+			violations = append(violations, violation(ref.path, "product group ID %s is not defined in the product tree", ref.id))
+		}
+	}
+	return violations
+}
+
+func testMultipleGroupDefinition(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	first := make(map[string]int)
+	var violations []Violation
+	for i, g := range doc.ProductTree.ProductGroups {
+		if j, ok := first[g.GroupID]; ok {
+			violations = append(violations, violation(
+				pointer("product_tree", "product_groups", i, "group_id"),
+				"product group ID %s is already defined at %s", g.GroupID, pointer("product_tree", "product_groups", j, "group_id")))
+			continue
+		}
+		first[g.GroupID] = i
+	}
+	return violations
+}
+
+// productStatusGroups maps product status lists to the mutually exclusive status groups of test 6.1.6
+var productStatusGroups = map[string]string{
+	"first_affected":      "affected",
+	"known_affected":      "affected",
+	"last_affected":       "affected",
+	"known_not_affected":  "not affected",
+	"first_fixed":         "fixed",
+	"fixed":               "fixed",
+	"under_investigation": "under investigation",
+}
+
+func testContradictingProductStatus(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		if vuln.ProductStatus == nil {
+			continue
+		}
+
+		statusOf := make(map[string]string)
+		for _, list := range productStatusLists(vuln.ProductStatus) {
+			group, ok := productStatusGroups[list.name]
+			if !ok {
+				continue
+			}
+			for i, id := range list.ids {
+				if previous, ok := statusOf[id]; ok && previous != group {
+					violations = append(violations, violation(
+						pointer("vulnerabilities", v, "product_status", list.name, i),
+						"product %s is listed as both %s and %s", id, previous, group))
+					continue
+				}
+				statusOf[id] = group
+			}
+		}
+	}
+	return violations
+}
+
+func testMultipleScores(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		seen := make(map[string]bool)
+		for s, score := range vuln.Scores {
+			var versions []string
+			if score.CVSSv2 != nil {
+				versions = append(versions, score.CVSSv2.Version)
+			}
+			if score.CVSSv3 != nil {
+				versions = append(versions, score.CVSSv3.Version)
+			}
+			for p, id := range score.Products {
+				for _, version := range versions {
+					key := id + "\x00" + version
+					if seen[key] {
+						violations = append(violations, violation(
+							pointer("vulnerabilities", v, "scores", s, "products", p),
+							"product %s has more than one CVSS v%s score", id, version))
+					}
+					seen[key] = true
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// toJSONValue converts a typed value to the generic representation used by the JSON schema validator
+func toJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	err = decoder.Decode(&generic)
+	return generic, err
+}
+
+func testInvalidCVSS(doc *csaf.Document) []Violation {
+	var violations []Violation
+	check := func(value any, version, path string) {
+		schema, ok := cvssSchemas[version]
+		if !ok {
+			violations = append(violations, violation(path+"/version", "unknown CVSS version %s", version))
+			return
+		}
+		generic, err := toJSONValue(value)
+		if err != nil {
+			violations = append(violations, violation(path, "failed to convert CVSS object: %v", err))
+			return
+		}
+		found, err := schema.validate(generic, path)
+		if err != nil {
+			violations = append(violations, violation(path, "%v", err))
+			return
+		}
+		violations = append(violations, found...)
+	}
+
+	for v, vuln := range doc.Vulnerabilities {
+		for s, score := range vuln.Scores {
+			if score.CVSSv2 != nil {
+				check(score.CVSSv2, score.CVSSv2.Version, pointer("vulnerabilities", v, "scores", s, "cvss_v2"))
+			}
+			if score.CVSSv3 != nil {
+				check(score.CVSSv3, score.CVSSv3.Version, pointer("vulnerabilities", v, "scores", s, "cvss_v3"))
+			}
+		}
+	}
+	return violations
+}
+
+// compareScore records a violation if a score given in the document differs from the computed value
+func compareScore(violations []Violation, path string, given *float64, computed float64) []Violation {
+	if given != nil && *given != computed {
+		return append(violations, violation(path, "score %.1f does not match the computed score %.1f", *given, computed))
+	}
+	return violations
+}
+
+func testInvalidCVSSComputation(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for s, score := range vuln.Scores {
+			if c := score.CVSSv3; c != nil {
+				path := pointer("vulnerabilities", v, "scores", s, "cvss_v3")
+				_, metrics, err := parseCVSSVector(c.VectorString)
+				if err == nil {
+					var scores cvssScores
+					scores, err = computeCVSS3(c.Version, metrics)
+					if err == nil {
+						violations = compareScore(violations, path+"/baseScore", &c.BaseScore, scores.base)
+						violations = compareScore(violations, path+"/temporalScore", c.TemporalScore, scores.temporal)
+						violations = compareScore(violations, path+"/environmentalScore", c.EnvironmentalScore, scores.environmental)
+						if c.BaseSeverity != cvss3Severity(c.BaseScore) {
+							violations = append(violations, violation(path+"/baseSeverity",
+								"severity %s does not match the base score %.1f", c.BaseSeverity, c.BaseScore))
+						}
+						if c.TemporalSeverity != "" && c.TemporalScore != nil && c.TemporalSeverity != cvss3Severity(*c.TemporalScore) {
+							violations = append(violations, violation(path+"/temporalSeverity",
+								"severity %s does not match the temporal score %.1f", c.TemporalSeverity, *c.TemporalScore))
+						}
+						if c.EnvironmentalSeverity != "" && c.EnvironmentalScore != nil && c.EnvironmentalSeverity != cvss3Severity(*c.EnvironmentalScore) {
+							violations = append(violations, violation(path+"/environmentalSeverity",
+								"severity %s does not match the environmental score %.1f", c.EnvironmentalSeverity, *c.EnvironmentalScore))
+						}
+					}
+				}
+				if err != nil {
+					violations = append(violations, violation(path+"/vectorString", "cannot compute CVSS score: %v", err))
+				}
+			}
+
+			if c := score.CVSSv2; c != nil {
+				path := pointer("vulnerabilities", v, "scores", s, "cvss_v2")
+				_, metrics, err := parseCVSSVector(c.VectorString)
+				if err == nil {
+					var scores cvssScores
+					scores, err = computeCVSS2(metrics)
+					if err == nil {
+						violations = compareScore(violations, path+"/baseScore", &c.BaseScore, scores.base)
+						violations = compareScore(violations, path+"/temporalScore", c.TemporalScore, scores.temporal)
+						violations = compareScore(violations, path+"/environmentalScore", c.EnvironmentalScore, scores.environmental)
+					}
+				}
+				if err != nil {
+					violations = append(violations, violation(path+"/vectorString", "cannot compute CVSS score: %v", err))
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// checkCVSSConsistency compares the properties of a CVSS object with the metrics of its vector
+func checkCVSSConsistency(value any, vector string, metrics []cvssMetric, path string) []Violation {
+	_, parsed, err := parseCVSSVector(vector)
+	if err != nil {
+		return []Violation{violation(path+"/vectorString", "%v", err)}
+	}
+
+	generic, err := toJSONValue(value)
+	if err != nil {
+		return []Violation{violation(path, "failed to convert CVSS object: %v", err)}
+	}
+	properties, _ := generic.(map[string]any)
+
+	var violations []Violation
+	for _, metric := range metrics {
+		property, ok := properties[metric.property].(string)
+		if !ok {
+			continue
+		}
+		expected := "NOT_DEFINED"
+		if abbreviation, ok := parsed[metric.key]; ok {
+			expected = metric.values[abbreviation]
+		}
+		if property != expected {
+			violations = append(violations, violation(path+"/"+metric.property,
+				"value %s does not match the vector string, which implies %s", property, expected))
+		}
+	}
+	return violations
+}
+
+func testInconsistentCVSS(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for s, score := range vuln.Scores {
+			if c := score.CVSSv3; c != nil {
+				path := pointer("vulnerabilities", v, "scores", s, "cvss_v3")
+				violations = append(violations, checkCVSSConsistency(c, c.VectorString, cvss3Metrics, path)...)
+				if prefix, _, err := parseCVSSVector(c.VectorString); err == nil && prefix != c.Version {
+					violations = append(violations, violation(path+"/version",
+						"version %s does not match the vector string, which implies %s", c.Version, prefix))
+				}
+			}
+			if c := score.CVSSv2; c != nil {
+				path := pointer("vulnerabilities", v, "scores", s, "cvss_v2")
+				violations = append(violations, checkCVSSConsistency(c, c.VectorString, cvss2Metrics, path)...)
+			}
+		}
+	}
+	return violations
+}
+
+func testLanguage(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for _, field := range []struct {
+		path  string
+		value string
+	}{
+		{"/document/lang", doc.Document.Lang},
+		{"/document/source_lang", doc.Document.SourceLang},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if _, err := language.Parse(field.value); err != nil {
+			violations = append(violations, violation(field.path, "%s is not a valid language code: %v", field.value, err))
+		}
+	}
+	return violations
+}
+
+// purlPattern matches the structure of a package URL: pkg:type/namespace/name@version?qualifiers#subpath
+var purlPattern = regexp.MustCompile(`^pkg:[A-Za-z.+-][A-Za-z0-9.+-]*/(?:[^/?#@]+/)*[^/?#@]+(?:@[^?#]+)?(?:\?[^#]+)?(?:#.+)?$`)
+
+// productHelpers calls fn for the product identification helper of every product defined in the product tree
+func productHelpers(doc *csaf.Document, fn func(helper *csaf.ProductIdentificationHelper, path string)) {
+	pt := doc.ProductTree
+	if pt == nil {
+		return
+	}
+
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Product != nil && b.Product.ProductIdentificationHelper != nil {
+				fn(b.Product.ProductIdentificationHelper, branchPath+"/product/product_identification_helper")
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(pt.Branches, "/product_tree/branches")
+
+	for i, fpn := range pt.FullProductNames {
+		if fpn.ProductIdentificationHelper != nil {
+			fn(fpn.ProductIdentificationHelper, pointer("product_tree", "full_product_names", i, "product_identification_helper"))
+		}
+	}
+	for i, rel := range pt.Relationships {
+		if rel.FullProductName.ProductIdentificationHelper != nil {
+			fn(rel.FullProductName.ProductIdentificationHelper,
+				pointer("product_tree", "relationships", i, "full_product_name", "product_identification_helper"))
+		}
+	}
+}
+
+func testPURL(doc *csaf.Document) []Violation {
+	var violations []Violation
+	productHelpers(doc, func(helper *csaf.ProductIdentificationHelper, path string) {
+		if helper.PURL != "" && !purlPattern.MatchString(helper.PURL) {
+			violations = append(violations, violation(path+"/purl", "%s is not a valid package URL", helper.PURL))
+		}
+	})
+	return violations
+}
+
+func testSortedRevisionHistory(doc *csaf.Document) []Violation {
+	revisions := revisionsByDate(doc.Document.Tracking.RevisionHistory)
+
+	var violations []Violation
+	for i := 1; i < len(revisions); i++ {
+		previous, current := revisions[i-1], revisions[i]
+		if !previous.parsedOK || !current.parsedOK {
+			continue
+		}
+		if current.version.compare(previous.version) < 0 {
+			violations = append(violations, violation(
+				pointer("document", "tracking", "revision_history", current.index, "number"),
+				"revision %s is dated after revision %s but has a lower number",
+				doc.Document.Tracking.RevisionHistory[current.index].Number,
+				doc.Document.Tracking.RevisionHistory[previous.index].Number))
+		}
+	}
+	return violations
+}
+
+func testTranslator(doc *csaf.Document) []Violation {
+	if doc.Document.Publisher.Category == "translator" && doc.Document.SourceLang == "" {
+		return []Violation{violation("/document/source_lang", "source language must be given if the publisher is a translator")}
+	}
+	return nil
+}
+
+func testLatestDocumentVersion(doc *csaf.Document) []Violation {
+	revisions := revisionsByDate(doc.Document.Tracking.RevisionHistory)
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	version, ok := parseVersion(doc.Document.Tracking.Version)
+	latest := revisions[len(revisions)-1]
+	if !ok || !latest.parsedOK {
+		return nil
+	}
+
+	// Build metadata is always ignored, a pre-release part only for drafts
+	if doc.Document.Tracking.Status == "draft" {
+		version.preRelease, latest.version.preRelease = "", ""
+	}
+	if version.compare(latest.version) != 0 || version.integer != latest.version.integer {
+		return []Violation{violation("/document/tracking/version",
+			"version %s does not match the latest revision history number %s",
+			doc.Document.Tracking.Version, doc.Document.Tracking.RevisionHistory[latest.index].Number)}
+	}
+	return nil
+}
+
+func testDocumentStatusDraft(doc *csaf.Document) []Violation {
+	version, ok := parseVersion(doc.Document.Tracking.Version)
+	if !ok || doc.Document.Tracking.Status == "draft" {
+		return nil
+	}
+	if version.isZero() || version.preRelease != "" {
+		return []Violation{violation("/document/tracking/status",
+			"status must be draft for version %s", doc.Document.Tracking.Version)}
+	}
+	return nil
+}
+
+func testReleasedRevision(doc *csaf.Document) []Violation {
+	status := doc.Document.Tracking.Status
+	if status != "final" && status != "interim" {
+		return nil
+	}
+
+	var violations []Violation
+	for i, r := range doc.Document.Tracking.RevisionHistory {
+		if version, ok := parseVersion(r.Number); ok && version.isZero() {
+			violations = append(violations, violation(
+				pointer("document", "tracking", "revision_history", i, "number"),
+				"revision %s must not be listed in a %s document", r.Number, status))
+		}
+	}
+	return violations
+}
+
+func testPreReleaseRevisions(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for i, r := range doc.Document.Tracking.RevisionHistory {
+		if version, ok := parseVersion(r.Number); ok && version.preRelease != "" {
+			violations = append(violations, violation(
+				pointer("document", "tracking", "revision_history", i, "number"),
+				"revision %s is a pre-release version", r.Number))
+		}
+	}
+	return violations
+}
+
+func testNonDraftVersion(doc *csaf.Document) []Violation {
+	status := doc.Document.Tracking.Status
+	if status != "final" && status != "interim" {
+		return nil
+	}
+	if version, ok := parseVersion(doc.Document.Tracking.Version); ok && version.preRelease != "" {
+		return []Violation{violation("/document/tracking/version",
+			"version %s of a %s document must not be a pre-release version", doc.Document.Tracking.Version, status)}
+	}
+	return nil
+}
+
+func testMissingRevision(doc *csaf.Document) []Violation {
+	revisions := revisionsByDate(doc.Document.Tracking.RevisionHistory)
+	if len(revisions) == 0 || !revisions[0].parsedOK {
+		return nil
+	}
+
+	var violations []Violation
+	history := doc.Document.Tracking.RevisionHistory
+
+	// For semantic versioning only the major version is considered
+	if first := revisions[0]; first.version.major > 1 {
+		violations = append(violations, violation(
+			pointer("document", "tracking", "revision_history", first.index, "number"),
+			"the oldest revision %s must have the (major) version 0 or 1", history[first.index].Number))
+	}
+
+	for i := 1; i < len(revisions); i++ {
+		previous, current := revisions[i-1], revisions[i]
+		if !previous.parsedOK || !current.parsedOK {
+			continue
+		}
+		if current.version.major > previous.version.major+1 {
+			violations = append(violations, violation(
+				pointer("document", "tracking", "revision_history", current.index, "number"),
+				"revision history is missing versions between %s and %s", history[previous.index].Number, history[current.index].Number))
+		}
+	}
+	return violations
+}
+
+func testMultipleRevisions(doc *csaf.Document) []Violation {
+	first := make(map[string]int)
+	var violations []Violation
+	for i, r := range doc.Document.Tracking.RevisionHistory {
+		if j, ok := first[r.Number]; ok {
+			violations = append(violations, violation(
+				pointer("document", "tracking", "revision_history", i, "number"),
+				"revision %s is already listed at %s", r.Number, pointer("document", "tracking", "revision_history", j, "number")))
+			continue
+		}
+		first[r.Number] = i
+	}
+	return violations
+}
+
+func testMultipleCVE(doc *csaf.Document) []Violation {
+	first := make(map[string]int)
+	var violations []Violation
+	for i, v := range doc.Vulnerabilities {
+		if v.CVE == "" {
+			continue
+		}
+		if j, ok := first[v.CVE]; ok {
+			violations = append(violations, violation(pointer("vulnerabilities", i, "cve"),
+				"%s is already used at %s", v.CVE, pointer("vulnerabilities", j, "cve")))
+			continue
+		}
+		first[v.CVE] = i
+	}
+	return violations
+}
+
+func testMultipleInvolvements(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		seen := make(map[string]bool)
+		for i, inv := range vuln.Involvements {
+			key := inv.Party + "\x00" + inv.Date
+			if seen[key] {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "involvements", i),
+					"involvement of party %s is already listed for date %s", inv.Party, inv.Date))
+			}
+			seen[key] = true
+		}
+	}
+	return violations
+}
+
+func testMultipleHashAlgorithms(doc *csaf.Document) []Violation {
+	var violations []Violation
+	productHelpers(doc, func(helper *csaf.ProductIdentificationHelper, path string) {
+		for h, hash := range helper.Hashes {
+			seen := make(map[string]bool)
+			for i, fh := range hash.FileHashes {
+				if seen[fh.Algorithm] {
+					violations = append(violations, violation(
+						fmt.Sprintf("%s/hashes/%d/file_hashes/%d/algorithm", path, h, i),
+						"hash algorithm %s is used more than once for %s", fh.Algorithm, hash.Filename))
+				}
+				seen[fh.Algorithm] = true
+			}
+		}
+	})
+	return violations
+}
+
+// profileCategories lists the document categories of the CSAF profiles other than CSAF Base
+var profileCategories = []string{
+	csaf.CategorySecurityIncident,
+	csaf.CategoryInformational,
+	csaf.CategorySecurityAdvisory,
+	csaf.CategoryVEX,
+}
+
+func testProhibitedCategory(doc *csaf.Document) []Violation {
+	category := doc.Document.Category
+	for _, c := range profileCategories {
+		if category == c {
+			return nil
+		}
+	}
+
+	normalize := func(s string) string {
+		return strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(s))
+	}
+	normalized := normalize(category)
+	for _, c := range profileCategories {
+		name := normalize(strings.TrimPrefix(c, "csaf_"))
+		if normalized == name || normalized == normalize(c) {
+			return []Violation{violation("/document/category",
+				"category %s is too similar to the profile category %s", category, c)}
+		}
+	}
+	return nil
+}
+
+// hasCategory checks if the document belongs to one of the given profiles
+func hasCategory(doc *csaf.Document, categories ...string) bool {
+	for _, c := range categories {
+		if doc.Document.Category == c {
+			return true
+		}
+	}
+	return false
+}
+
+func testProfileDocumentNotes(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategoryInformational, csaf.CategorySecurityIncident) {
+		return nil
+	}
+	for _, n := range doc.Document.Notes {
+		switch n.Category {
+		case "description", "details", "general", "summary":
+			return nil
+		}
+	}
+	return []Violation{violation("/document/notes",
+		"document must contain a note with category description, details, general or summary")}
+}
+
+func testProfileDocumentReferences(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategoryInformational, csaf.CategorySecurityIncident) {
+		return nil
+	}
+	for _, r := range doc.Document.References {
+		if r.Category == "external" {
+			return nil
+		}
+	}
+	return []Violation{violation("/document/references", "document must contain a reference with category external")}
+}
+
+func testProfileNoVulnerabilities(doc *csaf.Document) []Violation {
+	if hasCategory(doc, csaf.CategoryInformational) && doc.Vulnerabilities != nil {
+		return []Violation{violation("/vulnerabilities", "informational advisories must not contain vulnerabilities")}
+	}
+	return nil
+}
+
+func testProfileProductTree(doc *csaf.Document) []Violation {
+	if hasCategory(doc, csaf.CategorySecurityAdvisory, csaf.CategoryVEX) && doc.ProductTree == nil {
+		return []Violation{violation("/product_tree", "document must contain a product tree")}
+	}
+	return nil
+}
+
+func testProfileVulnerabilityNotes(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategorySecurityAdvisory, csaf.CategoryVEX) {
+		return nil
+	}
+	var violations []Violation
+	for i, v := range doc.Vulnerabilities {
+		if len(v.Notes) == 0 {
+			violations = append(violations, violation(pointer("vulnerabilities", i, "notes"), "vulnerability must contain notes"))
+		}
+	}
+	return violations
+}
+
+func testProfileProductStatus(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategorySecurityAdvisory) {
+		return nil
+	}
+	var violations []Violation
+	for i, v := range doc.Vulnerabilities {
+		if v.ProductStatus == nil {
+			violations = append(violations, violation(pointer("vulnerabilities", i, "product_status"),
+				"vulnerability must contain a product status"))
+		}
+	}
+	return violations
+}
+
+func testProfileVEXProductStatus(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategoryVEX) {
+		return nil
+	}
+	var violations []Violation
+	for i, v := range doc.Vulnerabilities {
+		ps := v.ProductStatus
+		if ps == nil || len(ps.Fixed)+len(ps.KnownAffected)+len(ps.KnownNotAffected)+len(ps.UnderInvestigation) == 0 {
+			violations = append(violations, violation(pointer("vulnerabilities", i, "product_status"),
+				"vulnerability must list products as fixed, known_affected, known_not_affected or under_investigation"))
+		}
+	}
+	return violations
+}
+
+func testProfileVulnerabilityID(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategoryVEX) {
+		return nil
+	}
+	var violations []Violation
+	for i, v := range doc.Vulnerabilities {
+		if v.CVE == "" && len(v.IDs) == 0 {
+			violations = append(violations, violation(pointer("vulnerabilities", i),
+				"vulnerability must contain a CVE or another ID"))
+		}
+	}
+	return violations
+}
+
+func testProfileImpactStatement(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategoryVEX) {
+		return nil
+	}
+
+	resolver := csaf.NewProductResolver(doc.ProductTree)
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		if vuln.ProductStatus == nil {
+			continue
+		}
+
+		justified := make(map[string]bool)
+		for _, f := range vuln.Flags {
+			for _, id := range resolver.Expand(f.ProductIDs, f.GroupIDs) {
+				justified[id] = true
+			}
+		}
+		for _, t := range vuln.Threats {
+			if t.Category == "impact" {
+				for _, id := range resolver.Expand(t.ProductIDs, t.GroupIDs) {
+					justified[id] = true
+				}
+			}
+		}
+
+		for i, id := range vuln.ProductStatus.KnownNotAffected {
+			if !justified[id] {
+				violations = append(violations, violation(
+					pointer("vulnerabilities", v, "product_status", "known_not_affected", i),
+					"product %s is not affected but has no impact statement", resolver.Label(id)))
+			}
+		}
+	}
+	return violations
+}
+
+func testProfileActionStatement(doc *csaf.Document) []Violation {
+	if !hasCategory(doc, csaf.CategoryVEX) {
+		return nil
+	}
+
+	resolver := csaf.NewProductResolver(doc.ProductTree)
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		if vuln.ProductStatus == nil {
+			continue
+		}
+
+		remediated := make(map[string]bool)
+		for _, r := range vuln.Remediations {
+			for _, id := range resolver.Expand(r.ProductIDs, r.GroupIDs) {
+				remediated[id] = true
+			}
+		}
+
+		for i, id := range vuln.ProductStatus.KnownAffected {
+			if !remediated[id] {
+				violations = append(violations, violation(
+					pointer("vulnerabilities", v, "product_status", "known_affected", i),
+					"product %s is affected but has no action statement", resolver.Label(id)))
+			}
+		}
+	}
+	return violations
+}
+
+func testProfileVulnerabilities(doc *csaf.Document) []Violation {
+	if hasCategory(doc, csaf.CategorySecurityAdvisory, csaf.CategoryVEX) && len(doc.Vulnerabilities) == 0 {
+		return []Violation{violation("/vulnerabilities", "document must contain vulnerabilities")}
+	}
+	return nil
+}
+
+func testTranslation(doc *csaf.Document) []Violation {
+	lang, sourceLang := doc.Document.Lang, doc.Document.SourceLang
+	if lang != "" && sourceLang != "" && strings.EqualFold(lang, sourceLang) {
+		return []Violation{violation("/document/source_lang",
+			"source language %s must differ from the document language", sourceLang)}
+	}
+	return nil
+}
+
+func testRemediationWithoutProduct(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for i, r := range vuln.Remediations {
+			if len(r.ProductIDs) == 0 && len(r.GroupIDs) == 0 {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "remediations", i),
+					"remediation does not refer to any product or product group"))
+			}
+		}
+	}
+	return violations
+}
+
+func testMixedVersioning(doc *csaf.Document) []Violation {
+	tracking := doc.Document.Tracking
+	version, ok := parseVersion(tracking.Version)
+	if !ok {
+		return nil
+	}
+
+	scheme := func(v documentVersion) string {
+		if v.integer {
+			return "integer"
+		}
+		return "semantic"
+	}
+
+	var violations []Violation
+	for i, r := range tracking.RevisionHistory {
+		if rv, ok := parseVersion(r.Number); ok && rv.integer != version.integer {
+			violations = append(violations, violation(
+				pointer("document", "tracking", "revision_history", i, "number"),
+				"revision %s uses %s versioning but the document version %s uses %s versioning",
+				r.Number, scheme(rv), tracking.Version, scheme(version)))
+		}
+	}
+	return violations
+}
+
+// versionRangePattern matches the operators and keywords that indicate a version range
+var versionRangePattern = regexp.MustCompile(`(?i)(<|>|\b(after|all|before|earlier|later|prior|versions)\b)`)
+
+func testVersionRange(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	var violations []Violation
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Category == "product_version" && versionRangePattern.MatchString(b.Name) {
+				violations = append(violations, violation(branchPath+"/name",
+					"product version %s describes a version range, use the product_version_range category instead", b.Name))
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(doc.ProductTree.Branches, "/product_tree/branches")
+	return violations
+}
+
+func testFlagWithoutProduct(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for i, f := range vuln.Flags {
+			if len(f.ProductIDs) == 0 && len(f.GroupIDs) == 0 {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "flags", i),
+					"flag does not refer to any product or product group"))
+			}
+		}
+	}
+	return violations
+}
+
+func testMultipleFlags(doc *csaf.Document) []Violation {
+	resolver := csaf.NewProductResolver(doc.ProductTree)
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		flagged := make(map[string]string)
+		for i, f := range vuln.Flags {
+			for _, id := range resolver.Expand(f.ProductIDs, f.GroupIDs) {
+				if previous, ok := flagged[id]; ok {
+					violations = append(violations, violation(pointer("vulnerabilities", v, "flags", i),
+						"product %s already has the justification %s", resolver.Label(id), previous))
+					continue
+				}
+				flagged[id] = f.Label
+			}
+		}
+	}
+	return violations
+}
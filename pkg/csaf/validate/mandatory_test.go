@@ -0,0 +1,692 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// testCase is a minimal document for a single test together with the JSON pointers of the
+// violations the test is expected to report, none for a passing document
+type testCase struct {
+	id       string
+	name     string
+	document string
+	want     []string
+}
+
+// runTestCases runs the test of every case on its document and compares the reported paths
+func runTestCases(t *testing.T, cases []testCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.id+" "+tc.name, func(t *testing.T) {
+			test, ok := testByID(tc.id)
+			if !ok {
+				t.Fatalf("test %s is not registered", tc.id)
+			}
+			var doc csaf.Document
+			if err := json.Unmarshal([]byte(tc.document), &doc); err != nil {
+				t.Fatalf("invalid test document: %v", err)
+			}
+
+			var got []string
+			for _, v := range test.Run(&doc) {
+				got = append(got, v.Path)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("%s reported %q, want %q", tc.id, got, tc.want)
+			}
+		})
+	}
+}
+
+// testByID returns the registered test with the given ID
+func testByID(id string) (Test, bool) {
+	for _, test := range AllTests() {
+		if test.ID == id {
+			return test, true
+		}
+	}
+	return Test{}, false
+}
+
+// assertCovered checks that every given test has a passing and a failing case
+func assertCovered(t *testing.T, tests []Test, cases []testCase) {
+	t.Helper()
+	passing, failing := make(map[string]bool), make(map[string]bool)
+	for _, tc := range cases {
+		if len(tc.want) == 0 {
+			passing[tc.id] = true
+		} else {
+			failing[tc.id] = true
+		}
+	}
+	for _, test := range tests {
+		if !passing[test.ID] || !failing[test.ID] {
+			t.Errorf("test %s needs a passing and a failing case", test.ID)
+		}
+	}
+}
+
+var mandatoryCases = []testCase{
+	{
+		id:       "6.1.1",
+		name:     "defined product",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1"}]}, "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1"]}}]}`,
+	},
+	{
+		id:       "6.1.1",
+		name:     "undefined product",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1"}]}, "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1", "CSAFPID-9"]}}]}`,
+		want:     []string{"/vulnerabilities/0/product_status/known_affected/1"},
+	},
+	{
+		id:       "6.1.2",
+		name:     "unique products",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_name", "name": "A", "product": {"name": "A", "product_id": "CSAFPID-1"}}]}], "full_product_names": [{"name": "B", "product_id": "CSAFPID-2"}]}}`,
+	},
+	{
+		id:       "6.1.2",
+		name:     "product defined in a branch and as full product name",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_name", "name": "A", "product": {"name": "A", "product_id": "CSAFPID-1"}}]}], "full_product_names": [{"name": "A", "product_id": "CSAFPID-1"}]}}`,
+		want:     []string{"/product_tree/full_product_names/0/product_id"},
+	},
+	{
+		id:       "6.1.3",
+		name:     "chained relationships",
+		document: `{"product_tree": {"relationships": [{"category": "default_component_of", "product_reference": "CSAFPID-1", "relates_to_product_reference": "CSAFPID-9", "full_product_name": {"name": "B", "product_id": "CSAFPID-2"}}, {"category": "installed_on", "product_reference": "CSAFPID-2", "relates_to_product_reference": "CSAFPID-9", "full_product_name": {"name": "C", "product_id": "CSAFPID-3"}}]}}`,
+	},
+	{
+		id:       "6.1.3",
+		name:     "product relating to itself",
+		document: `{"product_tree": {"relationships": [{"category": "installed_on", "product_reference": "CSAFPID-1", "relates_to_product_reference": "CSAFPID-9", "full_product_name": {"name": "A", "product_id": "CSAFPID-1"}}]}}`,
+		want:     []string{"/product_tree/relationships/0/full_product_name/product_id"},
+	},
+	{
+		id:       "6.1.3",
+		name:     "cycle through two relationships",
+		document: `{"product_tree": {"relationships": [{"category": "installed_on", "product_reference": "CSAFPID-2", "relates_to_product_reference": "CSAFPID-9", "full_product_name": {"name": "A", "product_id": "CSAFPID-1"}}, {"category": "installed_on", "product_reference": "CSAFPID-8", "relates_to_product_reference": "CSAFPID-1", "full_product_name": {"name": "B", "product_id": "CSAFPID-2"}}, {"category": "installed_on", "product_reference": "CSAFPID-1", "relates_to_product_reference": "CSAFPID-9", "full_product_name": {"name": "C", "product_id": "CSAFPID-3"}}]}}`,
+		want: []string{
+			"/product_tree/relationships/0/full_product_name/product_id",
+			"/product_tree/relationships/1/full_product_name/product_id",
+		},
+	},
+	{
+		id:       "6.1.4",
+		name:     "defined group",
+		document: `{"product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-2"]}]}, "vulnerabilities": [{"remediations": [{"category": "vendor_fix", "details": "Update", "group_ids": ["CSAFGID-1"]}]}]}`,
+	},
+	{
+		id:       "6.1.4",
+		name:     "undefined group",
+		document: `{"product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-2"]}]}, "vulnerabilities": [{"threats": [{"category": "impact", "details": "None", "group_ids": ["CSAFGID-2"]}]}]}`,
+		want:     []string{"/vulnerabilities/0/threats/0/group_ids/0"},
+	},
+	{
+		id:       "6.1.5",
+		name:     "unique groups",
+		document: `{"product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-2"]}, {"group_id": "CSAFGID-2", "product_ids": ["CSAFPID-1", "CSAFPID-3"]}]}}`,
+	},
+	{
+		id:       "6.1.5",
+		name:     "group defined twice",
+		document: `{"product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-2"]}, {"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-3"]}]}}`,
+		want:     []string{"/product_tree/product_groups/1/group_id"},
+	},
+	{
+		id:       "6.1.6",
+		name:     "statuses of the same group",
+		document: `{"vulnerabilities": [{"product_status": {"first_fixed": ["CSAFPID-1"], "fixed": ["CSAFPID-1"], "recommended": ["CSAFPID-1"]}}]}`,
+	},
+	{
+		id:       "6.1.6",
+		name:     "affected and not affected",
+		document: `{"vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1"], "known_not_affected": ["CSAFPID-2", "CSAFPID-1"]}}]}`,
+		want:     []string{"/vulnerabilities/0/product_status/known_not_affected/1"},
+	},
+	{
+		id:       "6.1.7",
+		name:     "scores of different versions",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.0", "vectorString": "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}, {"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+	},
+	{
+		id:       "6.1.7",
+		name:     "two scores of the same version",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}, {"products": ["CSAFPID-2", "CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", "baseScore": 7.8, "baseSeverity": "HIGH"}}]}]}`,
+		want:     []string{"/vulnerabilities/0/scores/1/products/1"},
+	},
+	{
+		id:       "6.1.8",
+		name:     "valid CVSS object",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+	},
+	{
+		id:       "6.1.8",
+		name:     "unknown severity",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "SEVERE"}}]}]}`,
+		want:     []string{"/vulnerabilities/0/scores/0/cvss_v3/baseSeverity"},
+	},
+	{
+		id:       "6.1.9",
+		name:     "computed scores",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", "baseScore": 9.8, "baseSeverity": "CRITICAL", "temporalScore": 8.8, "temporalSeverity": "HIGH"}, "cvss_v2": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:P/I:P/A:P", "baseScore": 7.5}}]}]}`,
+	},
+	{
+		id:       "6.1.9",
+		name:     "wrong scores and severity",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", "baseScore": 9.8, "baseSeverity": "HIGH", "temporalScore": 8.7}, "cvss_v2": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:P/I:P/A:P", "baseScore": 7.8}}]}]}`,
+		want: []string{
+			"/vulnerabilities/0/scores/0/cvss_v3/temporalScore",
+			"/vulnerabilities/0/scores/0/cvss_v3/baseSeverity",
+			"/vulnerabilities/0/scores/0/cvss_v2/baseScore",
+		},
+	},
+	{
+		id:       "6.1.10",
+		name:     "properties matching the vector",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "attackVector": "NETWORK", "exploitCodeMaturity": "NOT_DEFINED", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+	},
+	{
+		id:       "6.1.10",
+		name:     "properties and version contradicting the vector",
+		document: `{"vulnerabilities": [{"scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.0", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "attackVector": "LOCAL", "baseScore": 9.8, "baseSeverity": "CRITICAL"}, "cvss_v2": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:P/I:P/A:P", "authentication": "SINGLE", "baseScore": 7.5}}]}]}`,
+		want: []string{
+			"/vulnerabilities/0/scores/0/cvss_v3/attackVector",
+			"/vulnerabilities/0/scores/0/cvss_v3/version",
+			"/vulnerabilities/0/scores/0/cvss_v2/authentication",
+		},
+	},
+	{
+		id:       "6.1.12",
+		name:     "valid languages",
+		document: `{"document": {"lang": "en-US", "source_lang": "de"}}`,
+	},
+	{
+		id:       "6.1.12",
+		name:     "unknown language",
+		document: `{"document": {"lang": "EZ", "source_lang": "de"}}`,
+		want:     []string{"/document/lang"},
+	},
+	{
+		id:       "6.1.13",
+		name:     "valid package URL",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"purl": "pkg:maven/org.example/a@1.3.4?type=jar"}}]}}`,
+	},
+	{
+		id:       "6.1.13",
+		name:     "package URL without name",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"purl": "pkg:maven/@1.3.4"}}]}}`,
+		want:     []string{"/product_tree/full_product_names/0/product_identification_helper/purl"},
+	},
+	{
+		id:       "6.1.14",
+		name:     "sorted revision history",
+		document: `{"document": {"tracking": {"version": "2", "revision_history": [{"number": "2", "date": "2024-02-01T10:00:00Z"}, {"number": "1", "date": "2024-01-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.14",
+		name:     "later revision with lower number",
+		document: `{"document": {"tracking": {"version": "2", "revision_history": [{"number": "2", "date": "2024-01-01T10:00:00Z"}, {"number": "1", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/1/number"},
+	},
+	{
+		id:       "6.1.15",
+		name:     "translator with source language",
+		document: `{"document": {"lang": "de", "source_lang": "en", "publisher": {"category": "translator"}}}`,
+	},
+	{
+		id:       "6.1.15",
+		name:     "translator without source language",
+		document: `{"document": {"lang": "de", "publisher": {"category": "translator"}}}`,
+		want:     []string{"/document/source_lang"},
+	},
+	{
+		id:       "6.1.16",
+		name:     "latest revision",
+		document: `{"document": {"tracking": {"status": "final", "version": "2", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.16",
+		name:     "older revision",
+		document: `{"document": {"tracking": {"status": "final", "version": "1", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/version"},
+	},
+	{
+		id:       "6.1.17",
+		name:     "released version",
+		document: `{"document": {"tracking": {"status": "final", "version": "1.0.0"}}}`,
+	},
+	{
+		id:       "6.1.17",
+		name:     "unreleased version of a final document",
+		document: `{"document": {"tracking": {"status": "final", "version": "0.9.5"}}}`,
+		want:     []string{"/document/tracking/status"},
+	},
+	{
+		id:       "6.1.18",
+		name:     "released revisions",
+		document: `{"document": {"tracking": {"status": "final", "version": "2", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.18",
+		name:     "unreleased revision in a final document",
+		document: `{"document": {"tracking": {"status": "final", "version": "1", "revision_history": [{"number": "0", "date": "2024-01-01T10:00:00Z"}, {"number": "1", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/0/number"},
+	},
+	{
+		id:       "6.1.19",
+		name:     "release revisions",
+		document: `{"document": {"tracking": {"revision_history": [{"number": "1.0.0+build.1", "date": "2024-01-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.19",
+		name:     "pre-release revision",
+		document: `{"document": {"tracking": {"revision_history": [{"number": "1.0.0", "date": "2024-01-01T10:00:00Z"}, {"number": "1.1.0-rc.1", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/1/number"},
+	},
+	{
+		id:       "6.1.20",
+		name:     "pre-release version of a draft",
+		document: `{"document": {"tracking": {"status": "draft", "version": "1.0.0-rc.1"}}}`,
+	},
+	{
+		id:       "6.1.20",
+		name:     "pre-release version of an interim document",
+		document: `{"document": {"tracking": {"status": "interim", "version": "1.0.0-rc.1"}}}`,
+		want:     []string{"/document/tracking/version"},
+	},
+	{
+		id:       "6.1.21",
+		name:     "complete revision history",
+		document: `{"document": {"tracking": {"version": "3", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}, {"number": "3", "date": "2024-03-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.21",
+		name:     "missing revision",
+		document: `{"document": {"tracking": {"version": "3", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "3", "date": "2024-03-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/1/number"},
+	},
+	{
+		id:       "6.1.22",
+		name:     "distinct revisions",
+		document: `{"document": {"tracking": {"revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.22",
+		name:     "revision listed twice",
+		document: `{"document": {"tracking": {"revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "1", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/1/number"},
+	},
+	{
+		id:       "6.1.23",
+		name:     "distinct CVEs",
+		document: `{"vulnerabilities": [{"cve": "CVE-2024-0001"}, {"cve": "CVE-2024-0002"}, {}]}`,
+	},
+	{
+		id:       "6.1.23",
+		name:     "CVE used twice",
+		document: `{"vulnerabilities": [{"cve": "CVE-2024-0001"}, {}, {"cve": "CVE-2024-0001"}]}`,
+		want:     []string{"/vulnerabilities/2/cve"},
+	},
+	{
+		id:       "6.1.24",
+		name:     "involvements on different dates",
+		document: `{"vulnerabilities": [{"involvements": [{"party": "vendor", "status": "in_progress", "date": "2024-01-01T10:00:00Z"}, {"party": "vendor", "status": "completed", "date": "2024-02-01T10:00:00Z"}]}]}`,
+	},
+	{
+		id:       "6.1.24",
+		name:     "involvement listed twice",
+		document: `{"vulnerabilities": [{"involvements": [{"party": "vendor", "status": "in_progress", "date": "2024-01-01T10:00:00Z"}, {"party": "vendor", "status": "completed", "date": "2024-01-01T10:00:00Z"}]}]}`,
+		want:     []string{"/vulnerabilities/0/involvements/1"},
+	},
+	{
+		id:       "6.1.25",
+		name:     "distinct hash algorithms",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "sha256", "value": "abcd"}, {"algorithm": "sha512", "value": "abcd"}]}]}}]}}`,
+	},
+	{
+		id:       "6.1.25",
+		name:     "hash algorithm used twice",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "sha256", "value": "abcd"}, {"algorithm": "sha256", "value": "ef01"}]}]}}]}}`,
+		want:     []string{"/product_tree/full_product_names/0/product_identification_helper/hashes/0/file_hashes/1/algorithm"},
+	},
+	{
+		id:       "6.1.26",
+		name:     "custom category",
+		document: `{"document": {"category": "Example Company Security Notice"}}`,
+	},
+	{
+		id:       "6.1.26",
+		name:     "category resembling a profile",
+		document: `{"document": {"category": "Security_Advisory"}}`,
+		want:     []string{"/document/category"},
+	},
+	{
+		id:       "6.1.27.1",
+		name:     "informational advisory with summary",
+		document: `{"document": {"category": "csaf_informational_advisory", "notes": [{"category": "summary", "text": "Summary"}]}}`,
+	},
+	{
+		id:       "6.1.27.1",
+		name:     "informational advisory without summary",
+		document: `{"document": {"category": "csaf_informational_advisory", "notes": [{"category": "legal_disclaimer", "text": "Disclaimer"}]}}`,
+		want:     []string{"/document/notes"},
+	},
+	{
+		id:       "6.1.27.2",
+		name:     "external reference",
+		document: `{"document": {"category": "csaf_security_incident_response", "references": [{"category": "external", "summary": "Blog", "url": "https://example.com/blog"}]}}`,
+	},
+	{
+		id:       "6.1.27.2",
+		name:     "only self reference",
+		document: `{"document": {"category": "csaf_security_incident_response", "references": [{"category": "self", "summary": "Advisory", "url": "https://example.com/advisory.json"}]}}`,
+		want:     []string{"/document/references"},
+	},
+	{
+		id:       "6.1.27.3",
+		name:     "informational advisory without vulnerabilities",
+		document: `{"document": {"category": "csaf_informational_advisory"}}`,
+	},
+	{
+		id:       "6.1.27.3",
+		name:     "informational advisory with vulnerabilities",
+		document: `{"document": {"category": "csaf_informational_advisory"}, "vulnerabilities": [{"cve": "CVE-2024-0001"}]}`,
+		want:     []string{"/vulnerabilities"},
+	},
+	{
+		id:       "6.1.27.4",
+		name:     "security advisory with product tree",
+		document: `{"document": {"category": "csaf_security_advisory"}, "product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1"}]}}`,
+	},
+	{
+		id:       "6.1.27.4",
+		name:     "VEX without product tree",
+		document: `{"document": {"category": "csaf_vex"}}`,
+		want:     []string{"/product_tree"},
+	},
+	{
+		id:       "6.1.27.5",
+		name:     "vulnerability with notes",
+		document: `{"document": {"category": "csaf_security_advisory"}, "vulnerabilities": [{"notes": [{"category": "description", "text": "Description"}]}]}`,
+	},
+	{
+		id:       "6.1.27.5",
+		name:     "vulnerability without notes",
+		document: `{"document": {"category": "csaf_security_advisory"}, "vulnerabilities": [{"notes": [{"category": "description", "text": "Description"}]}, {}]}`,
+		want:     []string{"/vulnerabilities/1/notes"},
+	},
+	{
+		id:       "6.1.27.6",
+		name:     "vulnerability with product status",
+		document: `{"document": {"category": "csaf_security_advisory"}, "vulnerabilities": [{"product_status": {"fixed": ["CSAFPID-1"]}}]}`,
+	},
+	{
+		id:       "6.1.27.6",
+		name:     "vulnerability without product status",
+		document: `{"document": {"category": "csaf_security_advisory"}, "vulnerabilities": [{"cve": "CVE-2024-0001"}]}`,
+		want:     []string{"/vulnerabilities/0/product_status"},
+	},
+	{
+		id:       "6.1.27.7",
+		name:     "VEX status",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"product_status": {"under_investigation": ["CSAFPID-1"]}}]}`,
+	},
+	{
+		id:       "6.1.27.7",
+		name:     "only non-VEX status",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"product_status": {"first_affected": ["CSAFPID-1"]}}]}`,
+		want:     []string{"/vulnerabilities/0/product_status"},
+	},
+	{
+		id:       "6.1.27.8",
+		name:     "vulnerability with ID",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"cve": "CVE-2024-0001"}, {"ids": [{"system_name": "Tracker", "text": "BUG-1"}]}]}`,
+	},
+	{
+		id:       "6.1.27.8",
+		name:     "vulnerability without ID",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"cve": "CVE-2024-0001"}, {"notes": [{"category": "description", "text": "Description"}]}]}`,
+		want:     []string{"/vulnerabilities/1"},
+	},
+	{
+		id:       "6.1.27.9",
+		name:     "not affected products with flag and impact statement",
+		document: `{"document": {"category": "csaf_vex"}, "product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-2", "CSAFPID-3"]}]}, "vulnerabilities": [{"product_status": {"known_not_affected": ["CSAFPID-1", "CSAFPID-2", "CSAFPID-3"]}, "flags": [{"label": "component_not_present", "product_ids": ["CSAFPID-1"]}], "threats": [{"category": "impact", "details": "Not reachable", "group_ids": ["CSAFGID-1"]}]}]}`,
+	},
+	{
+		id:       "6.1.27.9",
+		name:     "not affected product without impact statement",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"product_status": {"known_not_affected": ["CSAFPID-1", "CSAFPID-2"]}, "threats": [{"category": "exploit_status", "details": "None", "product_ids": ["CSAFPID-2"]}, {"category": "impact", "details": "Not reachable", "product_ids": ["CSAFPID-1"]}]}]}`,
+		want:     []string{"/vulnerabilities/0/product_status/known_not_affected/1"},
+	},
+	{
+		id:       "6.1.27.10",
+		name:     "affected product with action statement",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1"]}, "remediations": [{"category": "workaround", "details": "Disable the service", "product_ids": ["CSAFPID-1"]}]}]}`,
+	},
+	{
+		id:       "6.1.27.10",
+		name:     "affected product without action statement",
+		document: `{"document": {"category": "csaf_vex"}, "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1", "CSAFPID-2"]}, "remediations": [{"category": "workaround", "details": "Disable the service", "product_ids": ["CSAFPID-1"]}]}]}`,
+		want:     []string{"/vulnerabilities/0/product_status/known_affected/1"},
+	},
+	{
+		id:       "6.1.27.11",
+		name:     "security advisory with vulnerabilities",
+		document: `{"document": {"category": "csaf_security_advisory"}, "vulnerabilities": [{"cve": "CVE-2024-0001"}]}`,
+	},
+	{
+		id:       "6.1.27.11",
+		name:     "security advisory without vulnerabilities",
+		document: `{"document": {"category": "csaf_security_advisory"}, "vulnerabilities": []}`,
+		want:     []string{"/vulnerabilities"},
+	},
+	{
+		id:       "6.1.28",
+		name:     "translated document",
+		document: `{"document": {"lang": "de", "source_lang": "en"}}`,
+	},
+	{
+		id:       "6.1.28",
+		name:     "same language in different case",
+		document: `{"document": {"lang": "en-US", "source_lang": "EN-us"}}`,
+		want:     []string{"/document/source_lang"},
+	},
+	{
+		id:       "6.1.29",
+		name:     "remediation for a group",
+		document: `{"vulnerabilities": [{"remediations": [{"category": "vendor_fix", "details": "Update", "group_ids": ["CSAFGID-1"]}]}]}`,
+	},
+	{
+		id:       "6.1.29",
+		name:     "remediation without products",
+		document: `{"vulnerabilities": [{"remediations": [{"category": "vendor_fix", "details": "Update", "product_ids": ["CSAFPID-1"]}, {"category": "vendor_fix", "details": "Update"}]}]}`,
+		want:     []string{"/vulnerabilities/0/remediations/1"},
+	},
+	{
+		id:       "6.1.30",
+		name:     "semantic versioning throughout",
+		document: `{"document": {"tracking": {"version": "2.0.0", "revision_history": [{"number": "1.0.0", "date": "2024-01-01T10:00:00Z"}, {"number": "2.0.0", "date": "2024-02-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.1.30",
+		name:     "integer version with semantic revision",
+		document: `{"document": {"tracking": {"version": "2", "revision_history": [{"number": "1.0.0", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/0/number"},
+	},
+	{
+		id:       "6.1.31",
+		name:     "single product version",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version", "name": "4.2"}, {"category": "product_version_range", "name": "vers:generic/<4.2"}]}]}}`,
+	},
+	{
+		id:       "6.1.31",
+		name:     "range in product version",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version", "name": "4.2"}, {"category": "product_version", "name": "prior to 4.2"}]}]}}`,
+		want:     []string{"/product_tree/branches/0/branches/1/name"},
+	},
+	{
+		id:       "6.1.32",
+		name:     "flag for a product",
+		document: `{"vulnerabilities": [{"flags": [{"label": "component_not_present", "product_ids": ["CSAFPID-1"]}]}]}`,
+	},
+	{
+		id:       "6.1.32",
+		name:     "flag without products",
+		document: `{"vulnerabilities": [{"flags": [{"label": "component_not_present"}]}]}`,
+		want:     []string{"/vulnerabilities/0/flags/0"},
+	},
+	{
+		id:       "6.1.33",
+		name:     "one flag per product",
+		document: `{"vulnerabilities": [{"flags": [{"label": "component_not_present", "product_ids": ["CSAFPID-1"]}, {"label": "vulnerable_code_not_present", "product_ids": ["CSAFPID-2"]}]}]}`,
+	},
+	{
+		id:       "6.1.33",
+		name:     "product flagged directly and through a group",
+		document: `{"product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-2"]}]}, "vulnerabilities": [{"flags": [{"label": "component_not_present", "product_ids": ["CSAFPID-1"]}, {"label": "vulnerable_code_not_present", "group_ids": ["CSAFGID-1"]}]}]}`,
+		want:     []string{"/vulnerabilities/0/flags/1"},
+	},
+}
+
+func TestMandatoryTests(t *testing.T) {
+	assertCovered(t, MandatoryTests(), mandatoryCases)
+	runTestCases(t, mandatoryCases)
+}
+
+func TestLatestDocumentVersion(t *testing.T) {
+	history := `[{"number": "0.9.0", "date": "2024-01-01T10:00:00Z"}, {"number": "1.0.0-rc.2", "date": "2024-02-01T10:00:00Z"}]`
+	runTestCases(t, []testCase{
+		{
+			id:       "6.1.16",
+			name:     "draft ignores the pre-release part",
+			document: `{"document": {"tracking": {"status": "draft", "version": "1.0.0-rc.3", "revision_history": ` + history + `}}}`,
+		},
+		{
+			id:       "6.1.16",
+			name:     "draft without pre-release part",
+			document: `{"document": {"tracking": {"status": "draft", "version": "1.0.0", "revision_history": ` + history + `}}}`,
+		},
+		{
+			id:       "6.1.16",
+			name:     "draft with another release",
+			document: `{"document": {"tracking": {"status": "draft", "version": "1.0.1-rc.2", "revision_history": ` + history + `}}}`,
+			want:     []string{"/document/tracking/version"},
+		},
+		{
+			id:       "6.1.16",
+			name:     "final compares the pre-release part",
+			document: `{"document": {"tracking": {"status": "final", "version": "1.0.0-rc.3", "revision_history": ` + history + `}}}`,
+			want:     []string{"/document/tracking/version"},
+		},
+		{
+			id:       "6.1.16",
+			name:     "build metadata is ignored",
+			document: `{"document": {"tracking": {"status": "final", "version": "1.0.0-rc.2+build.7", "revision_history": ` + history + `}}}`,
+		},
+		{
+			id:       "6.1.16",
+			name:     "latest revision by date, not by position",
+			document: `{"document": {"tracking": {"status": "final", "version": "2", "revision_history": [{"number": "2", "date": "2024-02-01T10:00:00Z"}, {"number": "1", "date": "2024-01-01T10:00:00Z"}]}}}`,
+		},
+		{
+			id:       "6.1.16",
+			name:     "integer version against a semantic revision",
+			document: `{"document": {"tracking": {"status": "final", "version": "1", "revision_history": [{"number": "1.0.0", "date": "2024-01-01T10:00:00Z"}]}}}`,
+			want:     []string{"/document/tracking/version"},
+		},
+	})
+}
+
+func TestMissingRevision(t *testing.T) {
+	revisions := func(numbers ...string) string {
+		history := make([]csaf.Revision, len(numbers))
+		for i, number := range numbers {
+			history[i] = csaf.Revision{Number: number, Date: fmt.Sprintf("2024-01-%02dT10:00:00Z", i+1)}
+		}
+		data, _ := json.Marshal(history)
+		return `{"document": {"tracking": {"revision_history": ` + string(data) + `}}}`
+	}
+	runTestCases(t, []testCase{
+		{id: "6.1.21", name: "integer from zero", document: revisions("0", "1", "2")},
+		{id: "6.1.21", name: "integer gap", document: revisions("1", "2", "4"), want: []string{"/document/tracking/revision_history/2/number"}},
+		{id: "6.1.21", name: "integer starting at two", document: revisions("2", "3"), want: []string{"/document/tracking/revision_history/0/number"}},
+		{id: "6.1.21", name: "semantic minor and patch releases", document: revisions("1.0.0", "1.0.1", "1.3.0", "2.0.0")},
+		{id: "6.1.21", name: "semantic from zero", document: revisions("0.1.0", "0.2.0", "1.0.0")},
+		{id: "6.1.21", name: "semantic major gap", document: revisions("1.0.0", "1.1.0", "3.0.0"), want: []string{"/document/tracking/revision_history/2/number"}},
+		{id: "6.1.21", name: "semantic starting at two", document: revisions("2.1.0", "3.0.0"), want: []string{"/document/tracking/revision_history/0/number"}},
+	})
+}
+
+func TestComputeCVSS3(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   cvssScores
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", cvssScores{9.8, 9.8, 9.8}},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", cvssScores{10, 10, 10}},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", cvssScores{6.1, 6.1, 6.1}},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", cvssScores{7.8, 7.0, 7.0}},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", cvssScores{0, 0, 0}},
+		// 10 * 0.92 is slightly above 9.2 in floating point; CVSS v3.0 rounds it up, v3.1 does not
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H/RC:U", cvssScores{10, 9.3, 9.3}},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H/RC:U", cvssScores{10, 9.2, 9.2}},
+		{"CVSS:3.0/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:N/A:N/RC:U", cvssScores{5.0, 4.7, 4.7}},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:N/A:N/RC:U", cvssScores{5.0, 4.6, 4.6}},
+		// The modified impact of a changed scope differs between CVSS v3.0 and v3.1
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N/CR:L/MS:C", cvssScores{9.1, 9.1, 9.6}},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N/CR:L/MS:C", cvssScores{9.1, 9.1, 9.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			version, metrics, err := parseCVSSVector(tt.vector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := computeCVSS3(version, metrics)
+			if err != nil {
+				t.Fatalf("computeCVSS3() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("computeCVSS3() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := computeCVSS3("3.1", map[string]string{"AV": "N"}); err == nil {
+		t.Error("computeCVSS3() accepted a vector without base metrics")
+	}
+}
+
+func TestComputeCVSS2(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   cvssScores
+	}{
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", cvssScores{7.5, 7.5, 7.5}},
+		{"AV:N/AC:M/Au:N/C:N/I:P/A:N", cvssScores{4.3, 4.3, 4.3}},
+		{"AV:N/AC:L/Au:N/C:C/I:C/A:C/E:F/RL:OF/RC:C", cvssScores{10, 8.3, 8.3}},
+		{"AV:L/AC:H/Au:M/C:N/I:N/A:N", cvssScores{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			_, metrics, err := parseCVSSVector(tt.vector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := computeCVSS2(metrics)
+			if err != nil {
+				t.Fatalf("computeCVSS2() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("computeCVSS2() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
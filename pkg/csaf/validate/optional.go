@@ -0,0 +1,376 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// The optional tests of section 6.2 of the CSAF 2.0 specification. Tests 6.2.13
+// (Sorting) and 6.2.20 (Additional Properties) need the key order and unknown keys of
+// the raw JSON document, which are lost when decoding it, and are not implemented.
+func init() {
+	registerTests(
+		Test{"6.2.1", "Unused Definition of Product ID", testUnusedProductDefinition},
+		Test{"6.2.2", "Missing Remediation", testMissingRemediation},
+		Test{"6.2.3", "Missing Score", testMissingScore},
+		Test{"6.2.4", "Build Metadata in Revision History", testBuildMetadata},
+		Test{"6.2.5", "Older Initial Release Date than Revision History", testOlderInitialReleaseDate},
+		Test{"6.2.6", "Older Current Release Date than Revision History", testOlderCurrentReleaseDate},
+		Test{"6.2.7", "Missing Date in Involvements", testMissingInvolvementDate},
+		Test{"6.2.8", "Use of MD5 as the only Hash Algorithm", testOnlyHashAlgorithm("md5")},
+		Test{"6.2.9", "Use of SHA-1 as the only Hash Algorithm", testOnlyHashAlgorithm("sha1")},
+		Test{"6.2.10", "Missing TLP label", testMissingTLPLabel},
+		Test{"6.2.11", "Missing Canonical URL", testMissingCanonicalURL},
+		Test{"6.2.12", "Missing Document Language", testMissingLanguage},
+		Test{"6.2.14", "Use of Private Language", testPrivateLanguage},
+		Test{"6.2.15", "Use of Default Language", testDefaultLanguage},
+		Test{"6.2.16", "Missing Product Identification Helper", testMissingProductHelper},
+		Test{"6.2.17", "CVE in field IDs", testCVEInIDs},
+		Test{"6.2.18", "Product Version Range without vers", testVersionRangeWithoutVers},
+		Test{"6.2.19", "CVSS for Fixed Products", testCVSSForFixedProducts},
+	)
+}
+
+// affectedStatusLists are the product status lists that make up the Affected group
+var affectedStatusLists = []string{"first_affected", "known_affected", "last_affected"}
+
+// statusListEntries calls fn for every product ID in the named product status lists of a vulnerability
+func statusListEntries(vuln csaf.Vulnerability, names []string, fn func(id, list string, index int)) {
+	if vuln.ProductStatus == nil {
+		return
+	}
+	for _, list := range productStatusLists(vuln.ProductStatus) {
+		for _, name := range names {
+			if list.name == name {
+				for i, id := range list.ids {
+					fn(id, list.name, i)
+				}
+			}
+		}
+	}
+}
+
+func testUnusedProductDefinition(doc *csaf.Document) []Violation {
+	used := make(map[string]bool)
+	for _, ref := range productReferences(doc) {
+		used[ref.id] = true
+	}
+
+	var violations []Violation
+	for _, def := range productDefinitions(doc) {
+		if !used[def.id] {
+			violations = append(violations, violation(def.path, "product ID %s is defined but never used", def.id))
+		}
+	}
+	return violations
+}
+
+func testMissingRemediation(doc *csaf.Document) []Violation {
+	resolver := csaf.NewProductResolver(doc.ProductTree)
+	lists := append([]string{"under_investigation"}, affectedStatusLists...)
+
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		remediated := make(map[string]bool)
+		for _, r := range vuln.Remediations {
+			for _, id := range resolver.Expand(r.ProductIDs, r.GroupIDs) {
+				remediated[id] = true
+			}
+		}
+		statusListEntries(vuln, lists, func(id, list string, i int) {
+			if !remediated[id] {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "product_status", list, i),
+					"product %s has no remediation", resolver.Label(id)))
+			}
+		})
+	}
+	return violations
+}
+
+func testMissingScore(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		scored := make(map[string]bool)
+		for _, s := range vuln.Scores {
+			for _, id := range s.Products {
+				scored[id] = true
+			}
+		}
+		statusListEntries(vuln, affectedStatusLists, func(id, list string, i int) {
+			if !scored[id] {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "product_status", list, i),
+					"affected product %s has no score", id))
+			}
+		})
+	}
+	return violations
+}
+
+func testBuildMetadata(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for i, r := range doc.Document.Tracking.RevisionHistory {
+		if version, ok := parseVersion(r.Number); ok && version.build != "" {
+			violations = append(violations, violation(pointer("document", "tracking", "revision_history", i, "number"),
+				"revision %s contains build metadata", r.Number))
+		}
+	}
+	return violations
+}
+
+// revisionDateRange returns the oldest and newest date in the revision history
+func revisionDateRange(history []csaf.Revision) (oldest, newest time.Time, ok bool) {
+	for _, r := range revisionsByDate(history) {
+		if !r.hasDate {
+			continue
+		}
+		if !ok {
+			oldest, ok = r.date, true
+		}
+		newest = r.date
+	}
+	return oldest, newest, ok
+}
+
+func testOlderInitialReleaseDate(doc *csaf.Document) []Violation {
+	tracking := doc.Document.Tracking
+	initial, err := time.Parse(time.RFC3339, tracking.InitialReleaseDate)
+	oldest, _, ok := revisionDateRange(tracking.RevisionHistory)
+	if err != nil || !ok || !initial.Before(oldest) {
+		return nil
+	}
+	return []Violation{violation("/document/tracking/initial_release_date",
+		"initial release date %s is older than the oldest revision history date %s",
+		tracking.InitialReleaseDate, oldest.Format(time.RFC3339))}
+}
+
+func testOlderCurrentReleaseDate(doc *csaf.Document) []Violation {
+	tracking := doc.Document.Tracking
+	current, err := time.Parse(time.RFC3339, tracking.CurrentReleaseDate)
+	_, newest, ok := revisionDateRange(tracking.RevisionHistory)
+	if err != nil || !ok || !current.Before(newest) {
+		return nil
+	}
+	return []Violation{violation("/document/tracking/current_release_date",
+		"current release date %s is older than the newest revision history date %s",
+		tracking.CurrentReleaseDate, newest.Format(time.RFC3339))}
+}
+
+func testMissingInvolvementDate(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for i, inv := range vuln.Involvements {
+			if inv.Date == "" {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "involvements", i),
+					"involvement of %s has no date", inv.Party))
+			}
+		}
+	}
+	return violations
+}
+
+// testOnlyHashAlgorithm returns a test that reports file hashes computed only with the given weak algorithm
+func testOnlyHashAlgorithm(algorithm string) func(doc *csaf.Document) []Violation {
+	return func(doc *csaf.Document) []Violation {
+		var violations []Violation
+		productHelpers(doc, func(helper *csaf.ProductIdentificationHelper, path string) {
+			for h, hash := range helper.Hashes {
+				if len(hash.FileHashes) == 0 {
+					continue
+				}
+				only := true
+				for _, fh := range hash.FileHashes {
+					if !strings.EqualFold(fh.Algorithm, algorithm) {
+						only = false
+						break
+					}
+				}
+				if only {
+					violations = append(violations, violation(fmt.Sprintf("%s/hashes/%d/file_hashes", path, h),
+						"%s is the only hash algorithm used for %s", algorithm, hash.Filename))
+				}
+			}
+		})
+		return violations
+	}
+}
+
+func testMissingTLPLabel(doc *csaf.Document) []Violation {
+	d := doc.Document.Distribution
+	if d != nil && d.TLP != nil && d.TLP.Label != "" {
+		return nil
+	}
+	return []Violation{violation("/document/distribution", "document has no TLP label")}
+}
+
+// filenameReplacePattern matches the characters that are replaced by an underscore in CSAF file names
+var filenameReplacePattern = regexp.MustCompile(`[^+\-a-z0-9]+`)
+
+// documentFilename returns the file name of a document as derived from its tracking ID in section 5.1
+func documentFilename(trackingID string) string {
+	return filenameReplacePattern.ReplaceAllString(strings.ToLower(trackingID), "_") + ".json"
+}
+
+func testMissingCanonicalURL(doc *csaf.Document) []Violation {
+	filename := documentFilename(doc.Document.Tracking.ID)
+	for _, r := range doc.Document.References {
+		if r.Category == "self" && strings.HasPrefix(r.URL, "https://") && strings.HasSuffix(r.URL, "/"+filename) {
+			return nil
+		}
+	}
+	return []Violation{violation("/document/references",
+		"document has no reference of category self with an https:// URL ending in %s", filename)}
+}
+
+func testMissingLanguage(doc *csaf.Document) []Violation {
+	if doc.Document.Lang != "" {
+		return nil
+	}
+	return []Violation{violation("/document", "document language is not set")}
+}
+
+// documentLanguages returns the language fields of the document that are set, keyed by their JSON pointer
+func documentLanguages(doc *csaf.Document) [][2]string {
+	var langs [][2]string
+	if doc.Document.Lang != "" {
+		langs = append(langs, [2]string{"/document/lang", doc.Document.Lang})
+	}
+	if doc.Document.SourceLang != "" {
+		langs = append(langs, [2]string{"/document/source_lang", doc.Document.SourceLang})
+	}
+	return langs
+}
+
+// isPrivateLanguage reports whether a language tag uses a subtag reserved for private use
+func isPrivateLanguage(tag string) bool {
+	subtags := strings.Split(strings.ToLower(tag), "-")
+	if primary := subtags[0]; len(primary) == 3 && primary >= "qaa" && primary <= "qtz" {
+		return true
+	}
+	for _, s := range subtags {
+		if s == "x" {
+			return true
+		}
+	}
+	return false
+}
+
+func testPrivateLanguage(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for _, lang := range documentLanguages(doc) {
+		if isPrivateLanguage(lang[1]) {
+			violations = append(violations, violation(lang[0], "language %s is reserved for private use", lang[1]))
+		}
+	}
+	return violations
+}
+
+func testDefaultLanguage(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for _, lang := range documentLanguages(doc) {
+		if strings.EqualFold(lang[1], "i-default") {
+			violations = append(violations, violation(lang[0], "language %s does not name a specific language", lang[1]))
+		}
+	}
+	return violations
+}
+
+func testMissingProductHelper(doc *csaf.Document) []Violation {
+	pt := doc.ProductTree
+	if pt == nil {
+		return nil
+	}
+
+	var violations []Violation
+	check := func(fpn csaf.FullProductName, path string) {
+		if fpn.ProductIdentificationHelper == nil {
+			violations = append(violations, violation(path,
+				"product %s has no product identification helper", fpn.ProductID))
+		}
+	}
+
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Product != nil {
+				check(*b.Product, branchPath+"/product")
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(pt.Branches, "/product_tree/branches")
+
+	for i, fpn := range pt.FullProductNames {
+		check(fpn, pointer("product_tree", "full_product_names", i))
+	}
+	for i, rel := range pt.Relationships {
+		check(rel.FullProductName, pointer("product_tree", "relationships", i, "full_product_name"))
+	}
+	return violations
+}
+
+// cvePattern matches a CVE ID as required by /vulnerabilities[]/cve
+var cvePattern = regexp.MustCompile(`^CVE-[0-9]{4}-[0-9]{4,}$`)
+
+func testCVEInIDs(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		for i, id := range vuln.IDs {
+			if cvePattern.MatchString(id.Text) {
+				violations = append(violations, violation(pointer("vulnerabilities", v, "ids", i, "text"),
+					"%s is a CVE ID and belongs in the cve field", id.Text))
+			}
+		}
+	}
+	return violations
+}
+
+func testVersionRangeWithoutVers(doc *csaf.Document) []Violation {
+	if doc.ProductTree == nil {
+		return nil
+	}
+
+	var violations []Violation
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Category == "product_version_range" && !strings.HasPrefix(b.Name, "vers:") {
+				violations = append(violations, violation(branchPath+"/name",
+					"product version range %s does not use the vers specification", b.Name))
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(doc.ProductTree.Branches, "/product_tree/branches")
+	return violations
+}
+
+func testCVSSForFixedProducts(doc *csaf.Document) []Violation {
+	var violations []Violation
+	for v, vuln := range doc.Vulnerabilities {
+		fixed := make(map[string]bool)
+		statusListEntries(vuln, []string{"first_fixed", "fixed"}, func(id, _ string, _ int) {
+			fixed[id] = true
+		})
+
+		for s, score := range vuln.Scores {
+			hasEnvironmental := (score.CVSSv3 == nil || score.CVSSv3.EnvironmentalScore != nil) &&
+				(score.CVSSv2 == nil || score.CVSSv2.EnvironmentalScore != nil)
+			if hasEnvironmental {
+				continue
+			}
+			for p, id := range score.Products {
+				if fixed[id] {
+					violations = append(violations, violation(pointer("vulnerabilities", v, "scores", s, "products", p),
+						"score for fixed product %s has no environmental score", id))
+				}
+			}
+		}
+	}
+	return violations
+}
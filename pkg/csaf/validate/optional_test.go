@@ -0,0 +1,209 @@
+package validate
+
+import "testing"
+
+var optionalCases = []testCase{
+	{
+		id:       "6.2.1",
+		name:     "used product",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1"}]}, "vulnerabilities": [{"product_status": {"fixed": ["CSAFPID-1"]}}]}`,
+	},
+	{
+		id:       "6.2.1",
+		name:     "unused product",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1"}, {"name": "B", "product_id": "CSAFPID-2"}]}, "vulnerabilities": [{"product_status": {"fixed": ["CSAFPID-1"]}}]}`,
+		want:     []string{"/product_tree/full_product_names/1/product_id"},
+	},
+	{
+		id:       "6.2.2",
+		name:     "remediation through a group",
+		document: `{"product_tree": {"product_groups": [{"group_id": "CSAFGID-1", "product_ids": ["CSAFPID-1", "CSAFPID-2"]}]}, "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1"], "under_investigation": ["CSAFPID-2"]}, "remediations": [{"category": "none_available", "details": "No fix", "group_ids": ["CSAFGID-1"]}]}]}`,
+	},
+	{
+		id:       "6.2.2",
+		name:     "affected product without remediation",
+		document: `{"vulnerabilities": [{"product_status": {"fixed": ["CSAFPID-3"], "known_affected": ["CSAFPID-1", "CSAFPID-2"]}, "remediations": [{"category": "vendor_fix", "details": "Update", "product_ids": ["CSAFPID-1"]}]}]}`,
+		want:     []string{"/vulnerabilities/0/product_status/known_affected/1"},
+	},
+	{
+		id:       "6.2.3",
+		name:     "scored affected product",
+		document: `{"vulnerabilities": [{"product_status": {"first_affected": ["CSAFPID-1"], "fixed": ["CSAFPID-2"]}, "scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+	},
+	{
+		id:       "6.2.3",
+		name:     "affected product without score",
+		document: `{"vulnerabilities": [{"product_status": {"last_affected": ["CSAFPID-1"]}}]}`,
+		want:     []string{"/vulnerabilities/0/product_status/last_affected/0"},
+	},
+	{
+		id:       "6.2.4",
+		name:     "revisions without build metadata",
+		document: `{"document": {"tracking": {"revision_history": [{"number": "1.0.0", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.2.4",
+		name:     "revision with build metadata",
+		document: `{"document": {"tracking": {"revision_history": [{"number": "1.0.0", "date": "2024-01-01T10:00:00Z"}, {"number": "1.0.1+exp.sha.5114f85", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/revision_history/1/number"},
+	},
+	{
+		id:       "6.2.5",
+		name:     "initial release date of the first revision",
+		document: `{"document": {"tracking": {"initial_release_date": "2024-01-01T10:00:00Z", "revision_history": [{"number": "2", "date": "2024-02-01T10:00:00Z"}, {"number": "1", "date": "2024-01-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.2.5",
+		name:     "initial release date before the first revision",
+		document: `{"document": {"tracking": {"initial_release_date": "2023-12-31T10:00:00Z", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/initial_release_date"},
+	},
+	{
+		id:       "6.2.6",
+		name:     "current release date of the last revision",
+		document: `{"document": {"tracking": {"current_release_date": "2024-02-01T10:00:00Z", "revision_history": [{"number": "2", "date": "2024-02-01T10:00:00Z"}, {"number": "1", "date": "2024-01-01T10:00:00Z"}]}}}`,
+	},
+	{
+		id:       "6.2.6",
+		name:     "current release date before the last revision",
+		document: `{"document": {"tracking": {"current_release_date": "2024-01-15T10:00:00Z", "revision_history": [{"number": "1", "date": "2024-01-01T10:00:00Z"}, {"number": "2", "date": "2024-02-01T10:00:00Z"}]}}}`,
+		want:     []string{"/document/tracking/current_release_date"},
+	},
+	{
+		id:       "6.2.7",
+		name:     "dated involvement",
+		document: `{"vulnerabilities": [{"involvements": [{"party": "vendor", "status": "completed", "date": "2024-01-01T10:00:00Z"}]}]}`,
+	},
+	{
+		id:       "6.2.7",
+		name:     "involvement without date",
+		document: `{"vulnerabilities": [{"involvements": [{"party": "vendor", "status": "completed", "date": "2024-01-01T10:00:00Z"}, {"party": "coordinator", "status": "open"}]}]}`,
+		want:     []string{"/vulnerabilities/0/involvements/1"},
+	},
+	{
+		id:       "6.2.8",
+		name:     "MD5 together with SHA-256",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "md5", "value": "abcd"}, {"algorithm": "sha256", "value": "abcd"}]}]}}]}}`,
+	},
+	{
+		id:       "6.2.8",
+		name:     "only MD5",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "MD5", "value": "abcd"}]}]}}]}}`,
+		want:     []string{"/product_tree/full_product_names/0/product_identification_helper/hashes/0/file_hashes"},
+	},
+	{
+		id:       "6.2.9",
+		name:     "SHA-1 together with SHA-256",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "sha1", "value": "abcd"}, {"algorithm": "sha256", "value": "abcd"}]}]}}]}}`,
+	},
+	{
+		id:       "6.2.9",
+		name:     "only SHA-1",
+		document: `{"product_tree": {"branches": [{"category": "product_name", "name": "A", "product": {"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"hashes": [{"filename": "a.tar.gz", "file_hashes": [{"algorithm": "sha1", "value": "abcd"}]}]}}}]}}`,
+		want:     []string{"/product_tree/branches/0/product/product_identification_helper/hashes/0/file_hashes"},
+	},
+	{
+		id:       "6.2.10",
+		name:     "TLP label",
+		document: `{"document": {"distribution": {"tlp": {"label": "WHITE"}}}}`,
+	},
+	{
+		id:       "6.2.10",
+		name:     "distribution without TLP label",
+		document: `{"document": {"distribution": {"text": "Share freely"}}}`,
+		want:     []string{"/document/distribution"},
+	},
+	{
+		id:       "6.2.11",
+		name:     "canonical URL",
+		document: `{"document": {"tracking": {"id": "Example Company - 2024-YH3234"}, "references": [{"category": "self", "summary": "Advisory", "url": "https://example.com/advisories/example_company_-_2024-yh3234.json"}]}}`,
+	},
+	{
+		id:       "6.2.11",
+		name:     "self reference without https",
+		document: `{"document": {"tracking": {"id": "Example Company - 2024-YH3234"}, "references": [{"category": "self", "summary": "Advisory", "url": "http://example.com/advisories/example_company_-_2024-yh3234.json"}]}}`,
+		want:     []string{"/document/references"},
+	},
+	{
+		id:       "6.2.12",
+		name:     "document language",
+		document: `{"document": {"lang": "en"}}`,
+	},
+	{
+		id:       "6.2.12",
+		name:     "no document language",
+		document: `{"document": {"source_lang": "en"}}`,
+		want:     []string{"/document"},
+	},
+	{
+		id:       "6.2.14",
+		name:     "public languages",
+		document: `{"document": {"lang": "en-US", "source_lang": "qu"}}`,
+	},
+	{
+		id:       "6.2.14",
+		name:     "private languages",
+		document: `{"document": {"lang": "qtx", "source_lang": "en-x-custom"}}`,
+		want:     []string{"/document/lang", "/document/source_lang"},
+	},
+	{
+		id:       "6.2.15",
+		name:     "specific language",
+		document: `{"document": {"lang": "de"}}`,
+	},
+	{
+		id:       "6.2.15",
+		name:     "default language",
+		document: `{"document": {"lang": "de", "source_lang": "i-default"}}`,
+		want:     []string{"/document/source_lang"},
+	},
+	{
+		id:       "6.2.16",
+		name:     "products with helpers",
+		document: `{"product_tree": {"full_product_names": [{"name": "A", "product_id": "CSAFPID-1", "product_identification_helper": {"cpe": "cpe:/a:example:a:1.0"}}]}}`,
+	},
+	{
+		id:       "6.2.16",
+		name:     "products without helpers",
+		document: `{"product_tree": {"branches": [{"category": "product_name", "name": "A", "product": {"name": "A", "product_id": "CSAFPID-1"}}], "full_product_names": [{"name": "B", "product_id": "CSAFPID-2", "product_identification_helper": {"cpe": "cpe:/a:example:b:1.0"}}], "relationships": [{"category": "installed_on", "product_reference": "CSAFPID-1", "relates_to_product_reference": "CSAFPID-2", "full_product_name": {"name": "A on B", "product_id": "CSAFPID-3"}}]}}`,
+		want:     []string{"/product_tree/branches/0/product", "/product_tree/relationships/0/full_product_name"},
+	},
+	{
+		id:       "6.2.17",
+		name:     "tracker ID",
+		document: `{"vulnerabilities": [{"cve": "CVE-2024-0001", "ids": [{"system_name": "Tracker", "text": "BUG-1"}]}]}`,
+	},
+	{
+		id:       "6.2.17",
+		name:     "CVE in IDs",
+		document: `{"vulnerabilities": [{"ids": [{"system_name": "Tracker", "text": "BUG-1"}, {"system_name": "CVE", "text": "CVE-2024-0001"}]}]}`,
+		want:     []string{"/vulnerabilities/0/ids/1/text"},
+	},
+	{
+		id:       "6.2.18",
+		name:     "vers range",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version_range", "name": "vers:generic/>=4.2|<4.5"}]}]}}`,
+	},
+	{
+		id:       "6.2.18",
+		name:     "range without vers",
+		document: `{"product_tree": {"branches": [{"category": "vendor", "name": "V", "branches": [{"category": "product_version_range", "name": ">=4.2 and <4.5"}]}]}}`,
+		want:     []string{"/product_tree/branches/0/branches/0/name"},
+	},
+	{
+		id:       "6.2.19",
+		name:     "environmental score for fixed product",
+		document: `{"vulnerabilities": [{"product_status": {"fixed": ["CSAFPID-1"]}, "scores": [{"products": ["CSAFPID-1"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/MC:N/MI:N/MA:N", "baseScore": 9.8, "baseSeverity": "CRITICAL", "environmentalScore": 0, "environmentalSeverity": "NONE"}}]}]}`,
+	},
+	{
+		id:       "6.2.19",
+		name:     "fixed product with base score only",
+		document: `{"vulnerabilities": [{"product_status": {"first_fixed": ["CSAFPID-2"], "known_affected": ["CSAFPID-1"]}, "scores": [{"products": ["CSAFPID-1", "CSAFPID-2"], "cvss_v3": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}]}`,
+		want:     []string{"/vulnerabilities/0/scores/0/products/1"},
+	},
+}
+
+func TestOptionalTests(t *testing.T) {
+	assertCovered(t, testsWithPrefix(profileSections[ProfileOptional]), optionalCases)
+	runTestCases(t, optionalCases)
+}
@@ -0,0 +1,114 @@
+package validate
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// The official CSAF 2.0 JSON schema and the FIRST CVSS schemas it references are
+// embedded so that documents can be validated without network access
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+const csafSchemaURL = "https://docs.oasis-open.org/csaf/csaf/v2.0/csaf_json_schema.json"
+
+// schemaURLs maps the URLs used in $ref keywords to the embedded schema files
+var schemaURLs = map[string]string{
+	csafSchemaURL: "schemas/csaf_json_schema.json",
+	"https://www.first.org/cvss/cvss-v2.0.json": "schemas/cvss-v2.0.json",
+	"https://www.first.org/cvss/cvss-v3.0.json": "schemas/cvss-v3.0.json",
+	"https://www.first.org/cvss/cvss-v3.1.json": "schemas/cvss-v3.1.json",
+}
+
+// embeddedSchema is a JSON schema that is compiled from the embedded schema files on first use
+type embeddedSchema struct {
+	url      string
+	once     sync.Once
+	compiled *jsonschema.Schema
+	err      error
+}
+
+var (
+	csafSchema  = &embeddedSchema{url: csafSchemaURL}
+	cvssSchemas = map[string]*embeddedSchema{
+		"2.0": {url: "https://www.first.org/cvss/cvss-v2.0.json"},
+		"3.0": {url: "https://www.first.org/cvss/cvss-v3.0.json"},
+		"3.1": {url: "https://www.first.org/cvss/cvss-v3.1.json"},
+	}
+)
+
+// loadEmbeddedSchema resolves schema URLs to the embedded schema files and refuses to fetch anything else
+func loadEmbeddedSchema(url string) (io.ReadCloser, error) {
+	name, ok := schemaURLs[url]
+	if !ok {
+		return nil, fmt.Errorf("schema %s is not embedded", url)
+	}
+	data, err := schemaFiles.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded schema %s: %w", name, err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// validate validates a decoded JSON value against the schema and returns all
+// violations sorted by their location, prefixed with the given JSON pointer
+func (s *embeddedSchema) validate(value any, prefix string) ([]Violation, error) {
+	s.once.Do(func() {
+		compiler := jsonschema.NewCompiler()
+		compiler.AssertFormat = true
+		compiler.LoadURL = loadEmbeddedSchema
+		s.compiled, s.err = compiler.Compile(s.url)
+		if s.err != nil {
+			s.err = fmt.Errorf("failed to compile JSON schema %s: %w", s.url, s.err)
+		}
+	})
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	err := s.compiled.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, fmt.Errorf("failed to validate document: %w", err)
+	}
+
+	var violations []Violation
+	seen := make(map[Violation]bool)
+	for _, unit := range validationErr.BasicOutput().Errors {
+		// Skip the summarizing errors of parent schemas that only point to their children
+		if unit.Error == "" || strings.HasPrefix(unit.Error, "doesn't validate with") {
+			continue
+		}
+		// Alternatives of a oneOf (e.g. CVSS v3.0 and v3.1) often report the same problem
+		v := Violation{Path: prefix + unit.InstanceLocation, Message: unit.Error}
+		if !seen[v] {
+			seen[v] = true
+			violations = append(violations, v)
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+
+	return violations, nil
+}
+
+// ValidateSchema validates a decoded JSON document against the CSAF 2.0 JSON schema
+// and returns all violations sorted by their location in the document
+func ValidateSchema(doc any) ([]Violation, error) {
+	return csafSchema.validate(doc, "")
+}
@@ -0,0 +1,297 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// Test is a single conformance test defined in section 6 of the CSAF specification
+type Test struct {
+	// ID is the number of the section that defines the test, e.g. "6.1.1"
+	ID   string
+	Name string
+	Run  func(doc *csaf.Document) []Violation
+}
+
+// Severity classifies how serious a violation is
+type Severity string
+
+// Severities of violations; schema violations and failed mandatory tests are errors,
+// failed optional tests are warnings and failed informative tests are informational
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Test groups as defined in sections 6.1, 6.2 and 6.3 of the specification
+const (
+	ProfileMandatory   = "mandatory"
+	ProfileOptional    = "optional"
+	ProfileInformative = "informative"
+	ProfileAll         = "all"
+)
+
+// profileSections maps each test group to the section that defines its tests
+var profileSections = map[string]string{
+	ProfileMandatory:   "6.1.",
+	ProfileOptional:    "6.2.",
+	ProfileInformative: "6.3.",
+}
+
+// Severity returns the severity of violations found by the test, which depends on its test group
+func (t Test) Severity() Severity {
+	switch {
+	case strings.HasPrefix(t.ID, profileSections[ProfileOptional]):
+		return SeverityWarning
+	case strings.HasPrefix(t.ID, profileSections[ProfileInformative]):
+		return SeverityInfo
+	default:
+		return SeverityError
+	}
+}
+
+// registeredTests holds all known tests in the order of the specification
+var registeredTests []Test
+
+// AllTests returns all tests in the order of the specification
+func AllTests() []Test {
+	return append([]Test{}, registeredTests...)
+}
+
+// MandatoryTests returns the mandatory tests of section 6.1 of the specification
+func MandatoryTests() []Test {
+	return testsWithPrefix(profileSections[ProfileMandatory])
+}
+
+// TestsForProfiles returns the tests of the given test groups (mandatory, optional,
+// informative or all) in the order of the specification
+func TestsForProfiles(profiles []string) ([]Test, error) {
+	selected := make(map[string]bool)
+	for _, profile := range profiles {
+		profile = strings.ToLower(strings.TrimSpace(profile))
+		if profile == ProfileAll {
+			return AllTests(), nil
+		}
+		if _, ok := profileSections[profile]; !ok {
+			return nil, fmt.Errorf("unknown profile '%s', expected one of %s, %s, %s or %s",
+				profile, ProfileMandatory, ProfileOptional, ProfileInformative, ProfileAll)
+		}
+		selected[profile] = true
+	}
+
+	var tests []Test
+	for _, t := range registeredTests {
+		for profile := range selected {
+			if strings.HasPrefix(t.ID, profileSections[profile]) {
+				tests = append(tests, t)
+				break
+			}
+		}
+	}
+	return tests, nil
+}
+
+// testsWithPrefix returns all tests whose ID starts with the given section prefix
+func testsWithPrefix(prefix string) []Test {
+	var tests []Test
+	for _, t := range registeredTests {
+		if strings.HasPrefix(t.ID, prefix) {
+			tests = append(tests, t)
+		}
+	}
+	return tests
+}
+
+// SelectTests returns the tests with the given IDs. An ID of a section that groups
+// several tests (e.g. "6.1.27") selects all tests within that section.
+func SelectTests(ids []string) ([]Test, error) {
+	selected := make(map[string]bool)
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		found := false
+		for _, t := range registeredTests {
+			if t.ID == id || strings.HasPrefix(t.ID, id+".") {
+				selected[t.ID] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown test '%s'", id)
+		}
+	}
+
+	var tests []Test
+	for _, t := range registeredTests {
+		if selected[t.ID] {
+			tests = append(tests, t)
+		}
+	}
+	return tests, nil
+}
+
+// registerTests adds tests to the registry, keeping it sorted by section number
+func registerTests(tests ...Test) {
+	registeredTests = append(registeredTests, tests...)
+	sort.SliceStable(registeredTests, func(i, j int) bool {
+		return compareSections(registeredTests[i].ID, registeredTests[j].ID) < 0
+	})
+}
+
+// compareSections compares dotted section numbers numerically, so that 6.1.2 sorts before 6.1.10
+func compareSections(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, _ := strconv.Atoi(as[i])
+		bn, _ := strconv.Atoi(bs[i])
+		if an != bn {
+			return an - bn
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// pointer builds a JSON pointer from a list of object keys and array indices
+func pointer(tokens ...any) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		switch t := token.(type) {
+		case int:
+			b.WriteString(strconv.Itoa(t))
+		case string:
+			b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
+		default:
+			b.WriteString(fmt.Sprint(t))
+		}
+	}
+	return b.String()
+}
+
+// productRef is a product or group ID together with the location where it occurs
+type productRef struct {
+	id   string
+	path string
+}
+
+// productDefinitions returns all product IDs defined in the product tree
+func productDefinitions(doc *csaf.Document) []productRef {
+	pt := doc.ProductTree
+	if pt == nil {
+		return nil
+	}
+
+	var defs []productRef
+	var walk func(branches []csaf.Branch, path string)
+	walk = func(branches []csaf.Branch, path string) {
+		for i, b := range branches {
+			branchPath := fmt.Sprintf("%s/%d", path, i)
+			if b.Product != nil {
+				defs = append(defs, productRef{b.Product.ProductID, branchPath + "/product/product_id"})
+			}
+			walk(b.Branches, branchPath+"/branches")
+		}
+	}
+	walk(pt.Branches, "/product_tree/branches")
+
+	for i, fpn := range pt.FullProductNames {
+		defs = append(defs, productRef{fpn.ProductID, pointer("product_tree", "full_product_names", i, "product_id")})
+	}
+	for i, rel := range pt.Relationships {
+		defs = append(defs, productRef{rel.FullProductName.ProductID, pointer("product_tree", "relationships", i, "full_product_name", "product_id")})
+	}
+
+	return defs
+}
+
+// productReferences returns all places in the document that refer to a product ID
+func productReferences(doc *csaf.Document) []productRef {
+	var refs []productRef
+	add := func(ids []string, tokens ...any) {
+		for i, id := range ids {
+			refs = append(refs, productRef{id, pointer(append(tokens, i)...)})
+		}
+	}
+
+	if pt := doc.ProductTree; pt != nil {
+		for i, g := range pt.ProductGroups {
+			add(g.ProductIDs, "product_tree", "product_groups", i, "product_ids")
+		}
+		for i, rel := range pt.Relationships {
+			refs = append(refs,
+				productRef{rel.ProductReference, pointer("product_tree", "relationships", i, "product_reference")},
+				productRef{rel.RelatesToProductReference, pointer("product_tree", "relationships", i, "relates_to_product_reference")},
+			)
+		}
+	}
+
+	for v, vuln := range doc.Vulnerabilities {
+		if ps := vuln.ProductStatus; ps != nil {
+			for _, s := range productStatusLists(ps) {
+				add(s.ids, "vulnerabilities", v, "product_status", s.name)
+			}
+		}
+		for i, r := range vuln.Remediations {
+			add(r.ProductIDs, "vulnerabilities", v, "remediations", i, "product_ids")
+		}
+		for i, s := range vuln.Scores {
+			add(s.Products, "vulnerabilities", v, "scores", i, "products")
+		}
+		for i, t := range vuln.Threats {
+			add(t.ProductIDs, "vulnerabilities", v, "threats", i, "product_ids")
+		}
+		for i, f := range vuln.Flags {
+			add(f.ProductIDs, "vulnerabilities", v, "flags", i, "product_ids")
+		}
+	}
+
+	return refs
+}
+
+// groupReferences returns all places in the document that refer to a product group ID
+func groupReferences(doc *csaf.Document) []productRef {
+	var refs []productRef
+	add := func(ids []string, tokens ...any) {
+		for i, id := range ids {
+			refs = append(refs, productRef{id, pointer(append(tokens, i)...)})
+		}
+	}
+
+	for v, vuln := range doc.Vulnerabilities {
+		for i, r := range vuln.Remediations {
+			add(r.GroupIDs, "vulnerabilities", v, "remediations", i, "group_ids")
+		}
+		for i, t := range vuln.Threats {
+			add(t.GroupIDs, "vulnerabilities", v, "threats", i, "group_ids")
+		}
+		for i, f := range vuln.Flags {
+			add(f.GroupIDs, "vulnerabilities", v, "flags", i, "group_ids")
+		}
+	}
+
+	return refs
+}
+
+// productStatusList is a named product status list of a vulnerability
+type productStatusList struct {
+	name string
+	ids  []string
+}
+
+// productStatusLists returns all product status lists of a vulnerability in schema order
+func productStatusLists(ps *csaf.ProductStatus) []productStatusList {
+	return []productStatusList{
+		{"first_affected", ps.FirstAffected},
+		{"first_fixed", ps.FirstFixed},
+		{"fixed", ps.Fixed},
+		{"known_affected", ps.KnownAffected},
+		{"known_not_affected", ps.KnownNotAffected},
+		{"last_affected", ps.LastAffected},
+		{"recommended", ps.Recommended},
+		{"under_investigation", ps.UnderInvestigation},
+	}
+}
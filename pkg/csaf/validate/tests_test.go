@@ -0,0 +1,126 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+// testIDs returns the IDs of the given tests
+func testIDs(tests []Test) []string {
+	ids := make([]string, len(tests))
+	for i, test := range tests {
+		ids[i] = test.ID
+	}
+	return ids
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		id   string
+		want Severity
+	}{
+		{"6.1.1", SeverityError},
+		{"6.1.27.11", SeverityError},
+		{"6.2.1", SeverityWarning},
+		{"6.2.19", SeverityWarning},
+		{"6.3.1", SeverityInfo},
+		{"6.3.11", SeverityInfo},
+	}
+	for _, tt := range tests {
+		if got := (Test{ID: tt.id}).Severity(); got != tt.want {
+			t.Errorf("Test{ID: %q}.Severity() = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+
+	// Every registered test is tagged by the section it belongs to
+	for _, test := range AllTests() {
+		want := map[string]Severity{"6.1.": SeverityError, "6.2.": SeverityWarning, "6.3.": SeverityInfo}[test.ID[:4]]
+		if got := test.Severity(); got != want {
+			t.Errorf("test %s has severity %s, want %s", test.ID, got, want)
+		}
+	}
+}
+
+func TestTestsForProfiles(t *testing.T) {
+	all := AllTests()
+	tests := []struct {
+		name     string
+		profiles []string
+		// prefixes are the sections of the selected tests
+		prefixes []string
+		wantErr  string
+	}{
+		{name: "mandatory", profiles: []string{"mandatory"}, prefixes: []string{"6.1."}},
+		{name: "optional", profiles: []string{"optional"}, prefixes: []string{"6.2."}},
+		{name: "informative", profiles: []string{"informative"}, prefixes: []string{"6.3."}},
+		{name: "optional and informative", profiles: []string{"informative", "optional"}, prefixes: []string{"6.2.", "6.3."}},
+		{name: "case and whitespace", profiles: []string{" Mandatory ", "OPTIONAL"}, prefixes: []string{"6.1.", "6.2."}},
+		{name: "all", profiles: []string{"mandatory", "all"}, prefixes: []string{"6.1.", "6.2.", "6.3."}},
+		{name: "unknown profile", profiles: []string{"mandatory", "strict"}, wantErr: "unknown profile 'strict'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TestsForProfiles(tt.profiles)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TestsForProfiles() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestsForProfiles() error = %v", err)
+			}
+
+			// The selected tests are those of the profiles, in the order of the specification
+			var want []string
+			for _, test := range all {
+				for _, prefix := range tt.prefixes {
+					if strings.HasPrefix(test.ID, prefix) {
+						want = append(want, test.ID)
+					}
+				}
+			}
+			if got, want := strings.Join(testIDs(got), " "), strings.Join(want, " "); got != want {
+				t.Errorf("TestsForProfiles() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSelectTests(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		want    string
+		wantErr string
+	}{
+		{name: "single tests in specification order", ids: []string{"6.3.2", "6.1.10", "6.1.2"}, want: "6.1.2 6.1.10 6.3.2"},
+		{name: "section prefix is not a test prefix", ids: []string{"6.1.2"}, want: "6.1.2"},
+		{
+			name: "section with subtests",
+			ids:  []string{"6.1.27"},
+			want: "6.1.27.1 6.1.27.2 6.1.27.3 6.1.27.4 6.1.27.5 6.1.27.6 6.1.27.7 6.1.27.8 6.1.27.9 6.1.27.10 6.1.27.11",
+		},
+		{name: "duplicates", ids: []string{"6.2.1", " 6.2.1"}, want: "6.2.1"},
+		{name: "unimplemented test", ids: []string{"6.1.11"}, wantErr: "unknown test '6.1.11'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectTests(tt.ids)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SelectTests() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectTests() error = %v", err)
+			}
+			if got := strings.Join(testIDs(got), " "); got != tt.want {
+				t.Errorf("SelectTests() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
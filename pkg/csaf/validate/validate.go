@@ -0,0 +1,209 @@
+// Package validate checks CSAF documents for conformance with the CSAF 2.0 specification.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+	"github.com/mprpic/csafx/pkg/csaf/cache"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+)
+
+// Violation is a single conformance problem found in a document
+type Violation struct {
+	// Test is the ID of the test that found the violation, empty for JSON schema violations
+	Test     string
+	Severity Severity
+	// Path is a JSON pointer to the offending part of the document
+	Path    string
+	Message string
+}
+
+// Result holds the outcome of validating a single document
+type Result struct {
+	Source     string
+	Violations []Violation
+	// Err is set if the document could not be read or parsed at all
+	Err error
+}
+
+// Valid reports whether the document was read successfully and has no errors;
+// warnings and informational findings do not make a document invalid
+func (r Result) Valid() bool {
+	return r.Err == nil && r.Count(SeverityError) == 0
+}
+
+// Count returns the number of violations with the given severity
+func (r Result) Count(severity Severity) int {
+	n := 0
+	for _, v := range r.Violations {
+		if v.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary aggregates the results of validating a set of documents
+type Summary struct {
+	Documents int
+	Valid     int
+	Invalid   int
+	Failed    int
+	Errors    int
+	Warnings  int
+	Infos     int
+}
+
+// Summarize counts valid, invalid and unreadable documents and all violations by severity
+func Summarize(results []Result) Summary {
+	s := Summary{Documents: len(results)}
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			s.Failed++
+		case r.Valid():
+			s.Valid++
+		default:
+			s.Invalid++
+		}
+		s.Errors += r.Count(SeverityError)
+		s.Warnings += r.Count(SeverityWarning)
+		s.Infos += r.Count(SeverityInfo)
+	}
+	return s
+}
+
+// Validator validates CSAF documents against the JSON schema and a set of tests
+type Validator struct {
+	// Tests are run on every document that can be decoded into the CSAF data model
+	Tests []Test
+}
+
+// NewValidator creates a validator that runs the given tests in addition to JSON schema validation
+func NewValidator(tests []Test) *Validator {
+	return &Validator{Tests: tests}
+}
+
+// Document validates the raw JSON data of a CSAF document
+func (v *Validator) Document(data []byte, source string) Result {
+	result := Result{Source: source}
+
+	// Keep numbers as json.Number so that scores are validated with their original precision
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		result.Err = fmt.Errorf("failed to parse JSON: %w", err)
+		return result
+	}
+
+	result.Violations, result.Err = ValidateSchema(doc)
+	for i := range result.Violations {
+		result.Violations[i].Severity = SeverityError
+	}
+	if result.Err != nil || len(v.Tests) == 0 {
+		return result
+	}
+
+	var typed csaf.Document
+	if err := json.Unmarshal(data, &typed); err != nil {
+		result.Violations = append(result.Violations, Violation{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("tests skipped, document does not match the CSAF data model: %v", err),
+		})
+		return result
+	}
+
+	for _, test := range v.Tests {
+		for _, violation := range test.Run(&typed) {
+			violation.Test = test.ID
+			violation.Severity = test.Severity()
+			result.Violations = append(result.Violations, violation)
+		}
+	}
+	return result
+}
+
+// File validates a CSAF document stored in a local file
+func (v *Validator) File(path string) Result {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{Source: path, Err: fmt.Errorf("failed to read file: %w", err)}
+	}
+	return v.Document(data, path)
+}
+
+// URL validates a CSAF document fetched from a remote URL
+func (v *Validator) URL(url string) Result {
+	resp, err := httpclient.Get(url)
+	if err != nil {
+		return Result{Source: url, Err: fmt.Errorf("failed to fetch URL: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{Source: url, Err: fmt.Errorf("HTTP request failed with status %d", resp.StatusCode)}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Result{Source: url, Err: fmt.Errorf("failed to read response body: %w", err)}
+	}
+
+	return v.Document(data, url)
+}
+
+// nonAdvisoryFiles lists JSON files found in CSAF directories and data sets that are not CSAF documents
+var nonAdvisoryFiles = map[string]bool{
+	"metadata.json":          true,
+	"provider-metadata.json": true,
+	"aggregator.json":        true,
+}
+
+// Directory validates all CSAF documents found in a directory tree, such as a
+// cached data set. The progress callback, if given, is called after each document.
+func (v *Validator) Directory(dir string, progress func(done, total int)) ([]Result, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == cache.QuarantineDir {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") || nonAdvisoryFiles[d.Name()] {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+
+	results := make([]Result, 0, len(files))
+	for i, path := range files {
+		result := v.File(path)
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			result.Source = rel
+		}
+		results = append(results, result)
+
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,69 @@
+package validate
+
+import "testing"
+
+// advisoryWithFindings is a schema-valid document that passes the mandatory tests but
+// has no remediation, score, TLP label, language, CVE or CWE
+const advisoryWithFindings = `{
+  "document": {
+    "category": "csaf_base",
+    "csaf_version": "2.0",
+    "publisher": {"category": "vendor", "name": "Example Company", "namespace": "https://example.com"},
+    "title": "Example advisory",
+    "tracking": {
+      "current_release_date": "2024-01-01T10:00:00Z",
+      "id": "EXAMPLE-2024-0001",
+      "initial_release_date": "2024-01-01T10:00:00Z",
+      "revision_history": [{"date": "2024-01-01T10:00:00Z", "number": "1", "summary": "Initial version"}],
+      "status": "final",
+      "version": "1"
+    }
+  },
+  "product_tree": {"full_product_names": [{"name": "Example Server 1.0", "product_id": "CSAFPID-1"}]},
+  "vulnerabilities": [{"product_status": {"known_affected": ["CSAFPID-1"]}}]
+}`
+
+func TestValidatorSeverities(t *testing.T) {
+	tests, err := TestsForProfiles([]string{ProfileAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := NewValidator(tests).Document([]byte(advisoryWithFindings), "advisory.json")
+	if result.Err != nil {
+		t.Fatalf("Document() error = %v", result.Err)
+	}
+
+	for _, v := range result.Violations {
+		test, ok := testByID(v.Test)
+		if !ok {
+			t.Errorf("unexpected violation %+v", v)
+			continue
+		}
+		if v.Severity != test.Severity() {
+			t.Errorf("violation of %s has severity %s, want %s", v.Test, v.Severity, test.Severity())
+		}
+	}
+	if result.Count(SeverityError) != 0 || result.Count(SeverityWarning) == 0 || result.Count(SeverityInfo) == 0 {
+		t.Fatalf("got %d errors, %d warnings and %d info, want only warnings and info: %+v", result.Count(SeverityError),
+			result.Count(SeverityWarning), result.Count(SeverityInfo), result.Violations)
+	}
+
+	// Warnings and informational findings do not fail validation
+	if !result.Valid() {
+		t.Error("Valid() = false for a document with only warnings and info")
+	}
+	summary := Summarize([]Result{result})
+	if summary.Valid != 1 || summary.Invalid != 0 {
+		t.Errorf("Summarize() = %+v, want the document counted as valid", summary)
+	}
+
+	// A failed mandatory test does
+	invalid := result
+	invalid.Violations = append(invalid.Violations, Violation{Test: "6.1.1", Severity: SeverityError})
+	if invalid.Valid() {
+		t.Error("Valid() = true for a document with an error")
+	}
+	if summary := Summarize([]Result{result, invalid}); summary.Valid != 1 || summary.Invalid != 1 {
+		t.Errorf("Summarize() = %+v, want one valid and one invalid document", summary)
+	}
+}
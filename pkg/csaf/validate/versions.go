@@ -0,0 +1,124 @@
+package validate
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+var (
+	integerVersionPattern  = regexp.MustCompile(`^(0|[1-9][0-9]*)$`)
+	semanticVersionPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+)
+
+// documentVersion is a parsed document version using either integer or semantic versioning
+type documentVersion struct {
+	integer    bool
+	major      int
+	minor      int
+	patch      int
+	preRelease string
+	build      string
+}
+
+// parseVersion parses a version string as used in /document/tracking/version
+func parseVersion(s string) (documentVersion, bool) {
+	if integerVersionPattern.MatchString(s) {
+		n, err := strconv.Atoi(s)
+		return documentVersion{integer: true, major: n}, err == nil
+	}
+
+	m := semanticVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return documentVersion{}, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return documentVersion{
+		major:      major,
+		minor:      minor,
+		patch:      patch,
+		preRelease: m[4],
+		build:      m[5],
+	}, true
+}
+
+// isZero reports whether the version is 0 or 0.y.z, which denotes a document that was never released
+func (v documentVersion) isZero() bool {
+	return v.major == 0
+}
+
+// compare orders versions by semantic versioning precedence, ignoring build metadata
+func (v documentVersion) compare(o documentVersion) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	return comparePreRelease(v.preRelease, o.preRelease)
+}
+
+// comparePreRelease compares pre-release identifiers as defined by semantic versioning
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// datedRevision is a revision history item with its parsed date and version
+type datedRevision struct {
+	index    int
+	date     time.Time
+	hasDate  bool
+	version  documentVersion
+	parsedOK bool
+}
+
+// revisionsByDate returns the revision history sorted ascending by date. Items whose
+// date cannot be parsed keep their relative position at the end of the list.
+func revisionsByDate(history []csaf.Revision) []datedRevision {
+	revisions := make([]datedRevision, len(history))
+	for i, r := range history {
+		date, dateErr := time.Parse(time.RFC3339, r.Date)
+		version, ok := parseVersion(r.Number)
+		revisions[i] = datedRevision{index: i, date: date, hasDate: dateErr == nil, version: version, parsedOK: ok}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		if revisions[i].hasDate != revisions[j].hasDate {
+			return revisions[i].hasDate
+		}
+		return revisions[i].date.Before(revisions[j].date)
+	})
+	return revisions
+}
@@ -6,28 +6,17 @@ import (
 	"io"
 	"net/http"
 	"os"
-)
-
-// Document represents a CSAF document with the minimal fields needed for viewing
-type Document struct {
-	Document DocumentFields `json:"document"`
-}
 
-// DocumentFields contains the main document metadata
-type DocumentFields struct {
-	Category string       `json:"category"`
-	Title    string       `json:"title"`
-	Tracking TrackingInfo `json:"tracking"`
-}
+	"github.com/mprpic/csafx/pkg/csaf"
+	"github.com/mprpic/csafx/pkg/csaf/httpclient"
+)
 
-// TrackingInfo contains document tracking information
-type TrackingInfo struct {
-	ID string `json:"id"`
-}
+// Document is the CSAF document type shared by all commands
+type Document = csaf.Document
 
 // ReadFromURL reads a CSAF document from a remote URL
 func ReadFromURL(url string) (Document, error) {
-	resp, err := http.Get(url)
+	resp, err := httpclient.Get(url)
 	if err != nil {
 		return Document{}, fmt.Errorf("failed to fetch URL %s: %w", url, err)
 	}
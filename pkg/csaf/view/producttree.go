@@ -0,0 +1,350 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// treeNode is a single collapsible entry in the product tree browser
+type treeNode struct {
+	label     string
+	productID string
+	helper    *csaf.ProductIdentificationHelper
+	details   []string
+	children  []*treeNode
+	parent    *treeNode
+	expanded  bool
+}
+
+// addChild appends a child node and links it back to its parent
+func (n *treeNode) addChild(child *treeNode) *treeNode {
+	child.parent = n
+	n.children = append(n.children, child)
+	return child
+}
+
+// treeRow is a node that is currently visible in the tree, along with its depth
+type treeRow struct {
+	node  *treeNode
+	depth int
+}
+
+// productTreeBrowser holds the product tree and its navigation state
+type productTreeBrowser struct {
+	roots  []*treeNode
+	byID   map[string]*treeNode
+	cursor int
+	offset int
+}
+
+// newProductTreeBrowser builds the browsable tree from the product_tree of a document
+func newProductTreeBrowser(pt *csaf.ProductTree, resolver *csaf.ProductResolver) productTreeBrowser {
+	b := productTreeBrowser{byID: make(map[string]*treeNode)}
+	if pt == nil {
+		return b
+	}
+
+	if len(pt.Branches) > 0 {
+		root := &treeNode{label: "Branches", expanded: true}
+		for _, branch := range pt.Branches {
+			b.addBranch(root, branch)
+		}
+		b.roots = append(b.roots, root)
+	}
+
+	if len(pt.FullProductNames) > 0 {
+		root := &treeNode{label: "Full product names", expanded: true}
+		for _, fpn := range pt.FullProductNames {
+			b.addProduct(root, fpn.Name, fpn)
+		}
+		b.roots = append(b.roots, root)
+	}
+
+	if len(pt.Relationships) > 0 {
+		root := &treeNode{label: "Relationships", expanded: true}
+		for _, rel := range pt.Relationships {
+			node := b.addProduct(root, rel.FullProductName.Name, rel.FullProductName)
+			node.details = append(node.details,
+				fmt.Sprintf("Relationship: %s %s %s", rel.ProductReference, rel.Category, rel.RelatesToProductReference),
+			)
+		}
+		b.roots = append(b.roots, root)
+	}
+
+	if len(pt.ProductGroups) > 0 {
+		root := &treeNode{label: "Product groups", expanded: true}
+		for _, group := range pt.ProductGroups {
+			label := group.GroupID
+			if group.Summary != "" {
+				label = fmt.Sprintf("%s — %s", group.GroupID, group.Summary)
+			}
+			node := root.addChild(&treeNode{label: label})
+			for _, id := range group.ProductIDs {
+				node.addChild(&treeNode{
+					label:   resolver.Label(id),
+					details: []string{"Member of group " + group.GroupID},
+				})
+			}
+		}
+		b.roots = append(b.roots, root)
+	}
+
+	return b
+}
+
+// addBranch recursively adds a branch and all its sub-branches below the given parent
+func (b *productTreeBrowser) addBranch(parent *treeNode, branch csaf.Branch) {
+	label := fmt.Sprintf("%s: %s", branch.Category, branch.Name)
+
+	var node *treeNode
+	if branch.Product != nil {
+		node = b.addProduct(parent, label, *branch.Product)
+	} else {
+		node = parent.addChild(&treeNode{label: label})
+	}
+
+	for _, child := range branch.Branches {
+		b.addBranch(node, child)
+	}
+}
+
+// addProduct adds a node that defines a product ID and indexes it for lookups
+func (b *productTreeBrowser) addProduct(parent *treeNode, label string, fpn csaf.FullProductName) *treeNode {
+	node := parent.addChild(&treeNode{
+		label:     fmt.Sprintf("%s [%s]", label, fpn.ProductID),
+		productID: fpn.ProductID,
+		helper:    fpn.ProductIdentificationHelper,
+		details:   []string{"Name: " + fpn.Name},
+	})
+	if _, exists := b.byID[fpn.ProductID]; !exists {
+		b.byID[fpn.ProductID] = node
+	}
+	return node
+}
+
+// rows returns all nodes that are currently visible, i.e. whose ancestors are all expanded
+func (b productTreeBrowser) rows() []treeRow {
+	var rows []treeRow
+	var walk func(nodes []*treeNode, depth int)
+	walk = func(nodes []*treeNode, depth int) {
+		for _, n := range nodes {
+			rows = append(rows, treeRow{node: n, depth: depth})
+			if n.expanded {
+				walk(n.children, depth+1)
+			}
+		}
+	}
+	walk(b.roots, 0)
+	return rows
+}
+
+// setExpanded expands or collapses every node in the tree
+func (b productTreeBrowser) setExpanded(expanded bool) {
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			if len(n.children) > 0 {
+				n.expanded = expanded
+			}
+			walk(n.children)
+		}
+	}
+	walk(b.roots)
+}
+
+// reveal expands all ancestors of the node defining the given product ID and moves
+// the cursor to it. It returns false if the product ID is not defined in the tree.
+func (b productTreeBrowser) reveal(productID string, height int) (productTreeBrowser, bool) {
+	target, ok := b.byID[productID]
+	if !ok {
+		return b, false
+	}
+
+	for p := target.parent; p != nil; p = p.parent {
+		p.expanded = true
+	}
+
+	for i, row := range b.rows() {
+		if row.node == target {
+			b.cursor = i
+			break
+		}
+	}
+
+	// Center the revealed node in the visible window
+	b.offset = max(b.cursor-b.treeHeight(height)/2, 0)
+	return b, true
+}
+
+// update handles key presses for the product tree tab
+func (b productTreeBrowser) update(msg tea.KeyMsg, height int) productTreeBrowser {
+	rows := b.rows()
+	if len(rows) == 0 {
+		return b
+	}
+	current := rows[b.cursor].node
+	visible := b.treeHeight(height)
+
+	switch msg.String() {
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, len(rows)-1)
+	case "pgup":
+		b.cursor = max(b.cursor-visible, 0)
+	case "pgdown":
+		b.cursor = min(b.cursor+visible, len(rows)-1)
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = len(rows) - 1
+	case "enter", " ":
+		if len(current.children) > 0 {
+			current.expanded = !current.expanded
+		}
+	case "right", "l":
+		if len(current.children) > 0 {
+			current.expanded = true
+		}
+	case "left", "h":
+		if current.expanded {
+			current.expanded = false
+		} else if current.parent != nil {
+			// Move to the parent node
+			for i, row := range rows {
+				if row.node == current.parent {
+					b.cursor = i
+					break
+				}
+			}
+		}
+	case "e":
+		b.setExpanded(true)
+	case "c":
+		b.setExpanded(false)
+		// Only the roots remain visible, move the cursor to the root of the current node
+		for current.parent != nil {
+			current = current.parent
+		}
+		for i, root := range b.roots {
+			if root == current {
+				b.cursor = i
+			}
+		}
+	}
+
+	b.cursor = min(b.cursor, len(b.rows())-1)
+
+	// Keep the cursor within the visible window
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	} else if b.cursor >= b.offset+visible {
+		b.offset = b.cursor - visible + 1
+	}
+
+	return b
+}
+
+// help returns the key bindings of the product tree tab
+func (b productTreeBrowser) help() string {
+	return "↑/↓ move • Enter toggle • ←/→ collapse/expand • e/c expand/collapse all"
+}
+
+// treeHeight returns the number of lines used by the tree, leaving the rest for the info pane
+func (b productTreeBrowser) treeHeight(height int) int {
+	return max(height-height/3, 1)
+}
+
+// view renders the visible part of the tree followed by information about the selected node
+func (b productTreeBrowser) view(width, height int) string {
+	rows := b.rows()
+	if len(rows) == 0 {
+		return faintStyle.Render("This document does not contain a product tree.")
+	}
+
+	var lines []string
+	visible := b.treeHeight(height)
+	end := min(b.offset+visible, len(rows))
+	for i := b.offset; i < end; i++ {
+		row := rows[i]
+
+		marker := "  "
+		if len(row.node.children) > 0 {
+			if row.node.expanded {
+				marker = "▾ "
+			} else {
+				marker = "▸ "
+			}
+		}
+
+		line := truncate(strings.Repeat("  ", row.depth)+marker+row.node.label, width)
+		switch {
+		case i == b.cursor:
+			line = selectedStyle.Render(line)
+		case row.depth == 0:
+			line = labelStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	// Pad the tree so that the info pane stays in place while scrolling
+	for len(lines) < visible {
+		lines = append(lines, "")
+	}
+
+	info := productInfoLines(rows[b.cursor].node)
+	infoHeight := height - visible
+	if len(info) > infoHeight {
+		info = info[:infoHeight]
+	}
+	lines = append(lines, info...)
+
+	return strings.Join(lines, "\n")
+}
+
+// productInfoLines renders the product identification helper and other details of a node
+func productInfoLines(n *treeNode) []string {
+	lines := []string{faintStyle.Render(strings.Repeat("─", 40))}
+
+	if n.productID != "" {
+		lines = append(lines, fmt.Sprintf("%s %s", labelStyle.Render("Product ID:"), n.productID))
+	}
+	lines = append(lines, n.details...)
+
+	h := n.helper
+	if h == nil {
+		return lines
+	}
+
+	field := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%s %s", labelStyle.Render(label), value))
+		}
+	}
+	list := func(label string, values []string) {
+		if len(values) > 0 {
+			field(label, strings.Join(values, ", "))
+		}
+	}
+
+	field("CPE:", h.CPE)
+	field("PURL:", h.PURL)
+	for _, hash := range h.Hashes {
+		for _, fh := range hash.FileHashes {
+			field("Hash:", fmt.Sprintf("%s %s:%s", hash.Filename, fh.Algorithm, fh.Value))
+		}
+	}
+	list("SBOM URLs:", h.SBOMURLs)
+	list("Model numbers:", h.ModelNumbers)
+	list("Serial numbers:", h.SerialNumbers)
+	list("SKUs:", h.SKUs)
+	for _, uri := range h.XGenericURIs {
+		field("URI:", fmt.Sprintf("%s (%s)", uri.URI, uri.Namespace))
+	}
+
+	return lines
+}
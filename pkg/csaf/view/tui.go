@@ -2,24 +2,92 @@ package view
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// tab identifies one of the top-level views of the TUI
+type tab int
+
+const (
+	tabOverview tab = iota
+	tabVulnerabilities
+	tabProductTree
+	tabVEXMatrix
+)
+
+var tabNames = map[tab]string{
+	tabOverview:        "Overview",
+	tabVulnerabilities: "Vulnerabilities",
+	tabProductTree:     "Product Tree",
+	tabVEXMatrix:       "VEX Status",
+}
+
+// Styling
+var (
+	titleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#7D56F4"))
+
+	labelStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#04B575"))
+
+	valueStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF"))
+
+	faintStyle = lipgloss.NewStyle().Faint(true)
+
+	selectedStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#7D56F4"))
+
+	activeTabStyle = lipgloss.NewStyle().
+			Bold(true).
+			Underline(true).
+			Foreground(lipgloss.Color("#7D56F4"))
+
+	inactiveTabStyle = lipgloss.NewStyle().Faint(true)
 )
 
 // model represents the Bubble Tea model for the TUI
 type model struct {
-	document Document
-	ready    bool
-	err      error
+	document  Document
+	ready     bool
+	err       error
+	width     int
+	height    int
+	tabs      []tab
+	activeTab tab
+	vulns     vulnerabilityList
+	tree      productTreeBrowser
+	vex       vexMatrix
+	status    string
 }
 
 // newModel creates a new Bubble Tea model with the given document
 func newModel(doc Document) model {
-	return model{
+	products := csaf.NewProductResolver(doc.ProductTree)
+	m := model{
 		document: doc,
 		ready:    true,
+		tabs:     []tab{tabOverview, tabVulnerabilities, tabProductTree},
+		vulns:    vulnerabilityList{products: products},
+		tree:     newProductTreeBrowser(doc.ProductTree, products),
+	}
+
+	// VEX documents are mostly read as a matrix of product statuses, so show it first
+	if doc.Document.Category == csaf.CategoryVEX {
+		m.tabs = []tab{tabOverview, tabVEXMatrix, tabVulnerabilities, tabProductTree}
+		m.vex = newVEXMatrix(doc, products)
 	}
+
+	return m
 }
 
 // Init implements the bubbletea.Model interface
@@ -30,15 +98,76 @@ func (m model) Init() tea.Cmd {
 // Update implements the bubbletea.Model interface
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
 	case tea.KeyMsg:
+		m.status = ""
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "tab":
+			m.activeTab = m.tabs[(m.tabIndex()+1)%len(m.tabs)]
+			return m, nil
+		case "shift+tab":
+			m.activeTab = m.tabs[(m.tabIndex()+len(m.tabs)-1)%len(m.tabs)]
+			return m, nil
+		}
+
+		switch m.activeTab {
+		case tabVulnerabilities:
+			var productID string
+			m.vulns, productID = m.vulns.update(msg, m.document.Vulnerabilities, m.bodyWidth(), m.bodyHeight())
+			if productID != "" {
+				var found bool
+				if m.tree, found = m.tree.reveal(productID, m.bodyHeight()); found {
+					m.activeTab = tabProductTree
+				} else {
+					m.status = fmt.Sprintf("Product %s is not defined in the product tree", productID)
+				}
+			}
+		case tabProductTree:
+			m.tree = m.tree.update(msg, m.bodyHeight())
+		case tabVEXMatrix:
+			m.vex = m.vex.update(msg, m.bodyWidth(), m.bodyHeight())
 		}
 	}
 	return m, nil
 }
 
+// tabIndex returns the position of the active tab in the tab bar
+func (m model) tabIndex() int {
+	for i, t := range m.tabs {
+		if t == m.activeTab {
+			return i
+		}
+	}
+	return 0
+}
+
+// bodyHeight returns the number of lines available for the content of the active tab
+func (m model) bodyHeight() int {
+	if m.height == 0 {
+		// No window size received yet, assume a standard terminal
+		return 20
+	}
+	// Title, tab bar, footer and the blank lines between them
+	overhead := 6
+	if m.status != "" {
+		overhead++
+	}
+	return max(m.height-overhead, 1)
+}
+
+// bodyWidth returns the number of columns available for the content of the active tab
+func (m model) bodyWidth() int {
+	if m.width == 0 {
+		return 80
+	}
+	return m.width
+}
+
 // View implements the bubbletea.Model interface
 func (m model) View() string {
 	if !m.ready {
@@ -49,54 +178,84 @@ func (m model) View() string {
 		return fmt.Sprintf("Error: %v\n\nPress 'q' to quit.", m.err)
 	}
 
-	// Styling
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#7D56F4"))
-	
-	labelStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#04B575"))
-	
-	valueStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF"))
-
 	// Format the document information
 	var docType string
 	category := m.document.Document.Category
-	
-	// TODO: Future enhancement - different rendering logic based on document type
-	// For now, we render the same TUI for both types, but this is where
-	// we could diverge the logic between 'csaf_vex' and 'csaf_security_advisory'
+
+	// The available tabs differ by document type (see newModel)
 	switch category {
-	case "csaf_vex":
+	case csaf.CategoryVEX:
 		docType = "VEX Document"
-	case "csaf_security_advisory":
+	case csaf.CategorySecurityAdvisory:
 		docType = "Security Advisory"
 	default:
 		docType = "CSAF Document"
 	}
 
-	content := fmt.Sprintf(
-		"%s\n\n%s %s\n\n%s %s\n\n%s %s\n\n%s",
+	var body, help string
+	switch m.activeTab {
+	case tabVulnerabilities:
+		body = m.vulns.view(m.document.Vulnerabilities, m.bodyWidth(), m.bodyHeight())
+		help = m.vulns.help()
+	case tabProductTree:
+		body = m.tree.view(m.bodyWidth(), m.bodyHeight())
+		help = m.tree.help()
+	case tabVEXMatrix:
+		body = m.vex.view(m.bodyWidth(), m.bodyHeight())
+		help = m.vex.help()
+	default:
+		body = m.overviewView()
+	}
+
+	footer := "Press 'q' or Ctrl+C to quit, Tab to switch views"
+	if help != "" {
+		footer = help + " • " + footer
+	}
+	footer = faintStyle.Render(footer)
+	if m.status != "" {
+		footer = labelStyle.Render(m.status) + "\n" + footer
+	}
+
+	return fmt.Sprintf(
+		"%s\n%s\n\n%s\n\n%s",
 		titleStyle.Render(fmt.Sprintf("CSAF %s Viewer", docType)),
+		m.tabBarView(),
+		body,
+		footer,
+	)
+}
+
+// tabBarView renders the list of tabs with the active one highlighted
+func (m model) tabBarView() string {
+	var tabs []string
+	for _, t := range m.tabs {
+		if t == m.activeTab {
+			tabs = append(tabs, activeTabStyle.Render(tabNames[t]))
+		} else {
+			tabs = append(tabs, inactiveTabStyle.Render(tabNames[t]))
+		}
+	}
+	return strings.Join(tabs, "  ")
+}
+
+// overviewView renders the document metadata shown in the overview tab
+func (m model) overviewView() string {
+	return fmt.Sprintf(
+		"%s %s\n\n%s %s\n\n%s %s",
 		labelStyle.Render("ID:"),
 		valueStyle.Render(m.document.Document.Tracking.ID),
 		labelStyle.Render("Title:"),
 		valueStyle.Render(m.document.Document.Title),
 		labelStyle.Render("Category:"),
-		valueStyle.Render(category),
-		lipgloss.NewStyle().Faint(true).Render("Press 'q' or Ctrl+C to quit"),
+		valueStyle.Render(m.document.Document.Category),
 	)
-
-	return content
 }
 
 // RunTUI starts the Bubble Tea TUI program for viewing a CSAF document
 func RunTUI(doc Document) error {
 	m := newModel(doc)
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	
+
 	_, err := p.Run()
 	return err
 }
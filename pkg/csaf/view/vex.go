@@ -0,0 +1,282 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// VEX product statuses shown in the status matrix
+const (
+	vexKnownAffected      = "known_affected"
+	vexKnownNotAffected   = "known_not_affected"
+	vexFixed              = "fixed"
+	vexUnderInvestigation = "under_investigation"
+)
+
+// vexCellStyles maps each VEX status to the label and style of its cell
+var vexCellStyles = map[string]struct {
+	label string
+	style lipgloss.Style
+}{
+	vexKnownAffected:      {"affected", lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F"))},
+	vexKnownNotAffected:   {"not affected", lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))},
+	vexFixed:              {"fixed", lipgloss.NewStyle().Foreground(lipgloss.Color("#5FAFFF"))},
+	vexUnderInvestigation: {"investigating", lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00"))},
+}
+
+const vexCellWidth = 15
+
+// vexMatrix holds the products × vulnerabilities status grid of a VEX document and its navigation state
+type vexMatrix struct {
+	products  []string
+	vulns     []csaf.Vulnerability
+	resolver  *csaf.ProductResolver
+	row       int
+	col       int
+	rowOffset int
+	colOffset int
+}
+
+// newVEXMatrix collects all products mentioned in the product status of any vulnerability
+func newVEXMatrix(doc Document, resolver *csaf.ProductResolver) vexMatrix {
+	m := vexMatrix{
+		vulns:    doc.Vulnerabilities,
+		resolver: resolver,
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range doc.Vulnerabilities {
+		for _, id := range productStatusIDs(v.ProductStatus) {
+			if !seen[id] {
+				seen[id] = true
+				m.products = append(m.products, id)
+			}
+		}
+	}
+
+	return m
+}
+
+// status returns the VEX status of a product for a vulnerability, or an empty string if none is given
+func (m vexMatrix) status(productID string, v csaf.Vulnerability) string {
+	if v.ProductStatus == nil {
+		return ""
+	}
+	for _, s := range []struct {
+		name string
+		ids  []string
+	}{
+		{vexKnownAffected, v.ProductStatus.KnownAffected},
+		{vexKnownNotAffected, v.ProductStatus.KnownNotAffected},
+		{vexFixed, v.ProductStatus.Fixed},
+		{vexUnderInvestigation, v.ProductStatus.UnderInvestigation},
+	} {
+		for _, id := range s.ids {
+			if id == productID {
+				return s.name
+			}
+		}
+	}
+	return ""
+}
+
+// appliesTo checks if a set of product and group IDs includes the given product
+func (m vexMatrix) appliesTo(productID string, productIDs, groupIDs []string) bool {
+	for _, id := range m.resolver.Expand(productIDs, groupIDs) {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// justification returns the flag labels and impact statements that apply to a product for a vulnerability
+func (m vexMatrix) justification(productID string, v csaf.Vulnerability) ([]string, []string) {
+	var flags, impacts []string
+	for _, f := range v.Flags {
+		if m.appliesTo(productID, f.ProductIDs, f.GroupIDs) {
+			flags = append(flags, f.Label)
+		}
+	}
+	for _, t := range v.Threats {
+		if t.Category == "impact" && m.appliesTo(productID, t.ProductIDs, t.GroupIDs) {
+			impacts = append(impacts, t.Details)
+		}
+	}
+	return flags, impacts
+}
+
+// productColumnWidth returns the width of the product name column, which fits the
+// longest product name but takes up at most half of the screen
+func (m vexMatrix) productColumnWidth(width int) int {
+	longest := len("Product")
+	for _, id := range m.products {
+		longest = max(longest, len([]rune(m.resolver.Name(id))))
+	}
+	return max(min(longest, width/2), 20)
+}
+
+// visibleColumns returns the number of vulnerability columns that fit into the given width
+func (m vexMatrix) visibleColumns(width int) int {
+	return max((width-m.productColumnWidth(width))/(vexCellWidth+1), 1)
+}
+
+// visibleRows returns the number of product rows that fit above the info pane
+func (m vexMatrix) visibleRows(height int) int {
+	// One line for the column header and a few lines for the selected cell
+	return max(height-height/3-1, 1)
+}
+
+// update handles key presses for the VEX matrix tab
+func (m vexMatrix) update(msg tea.KeyMsg, width, height int) vexMatrix {
+	if len(m.products) == 0 || len(m.vulns) == 0 {
+		return m
+	}
+
+	rows := m.visibleRows(height)
+	cols := m.visibleColumns(width)
+
+	switch msg.String() {
+	case "up", "k":
+		m.row = max(m.row-1, 0)
+	case "down", "j":
+		m.row = min(m.row+1, len(m.products)-1)
+	case "left", "h":
+		m.col = max(m.col-1, 0)
+	case "right", "l":
+		m.col = min(m.col+1, len(m.vulns)-1)
+	case "pgup":
+		m.row = max(m.row-rows, 0)
+	case "pgdown":
+		m.row = min(m.row+rows, len(m.products)-1)
+	case "home", "g":
+		m.row, m.col = 0, 0
+	case "end", "G":
+		m.row, m.col = len(m.products)-1, len(m.vulns)-1
+	}
+
+	// Keep the selected cell within the visible window
+	if m.row < m.rowOffset {
+		m.rowOffset = m.row
+	} else if m.row >= m.rowOffset+rows {
+		m.rowOffset = m.row - rows + 1
+	}
+	if m.col < m.colOffset {
+		m.colOffset = m.col
+	} else if m.col >= m.colOffset+cols {
+		m.colOffset = m.col - cols + 1
+	}
+
+	return m
+}
+
+// help returns the key bindings of the VEX matrix tab
+func (m vexMatrix) help() string {
+	return "↑/↓/←/→ move between cells"
+}
+
+// view renders the visible part of the status grid followed by details of the selected cell
+func (m vexMatrix) view(width, height int) string {
+	if len(m.products) == 0 || len(m.vulns) == 0 {
+		return faintStyle.Render("This document does not contain any product status information.")
+	}
+
+	rows := m.visibleRows(height)
+	cols := m.visibleColumns(width)
+	productWidth := m.productColumnWidth(width)
+	colEnd := min(m.colOffset+cols, len(m.vulns))
+	rowEnd := min(m.rowOffset+rows, len(m.products))
+
+	cell := func(s string, w int) string {
+		return fmt.Sprintf("%-*s", w, truncate(s, w))
+	}
+
+	header := cell("Product", productWidth)
+	for c := m.colOffset; c < colEnd; c++ {
+		header += " " + cell(vulnerabilityName(m.vulns[c]), vexCellWidth)
+	}
+	lines := []string{labelStyle.Render(header)}
+
+	for r := m.rowOffset; r < rowEnd; r++ {
+		productID := m.products[r]
+		line := cell(m.resolver.Name(productID), productWidth)
+		if r == m.row {
+			line = valueStyle.Bold(true).Render(line)
+		}
+
+		for c := m.colOffset; c < colEnd; c++ {
+			text := "-"
+			style := faintStyle
+			if cs, ok := vexCellStyles[m.status(productID, m.vulns[c])]; ok {
+				text, style = cs.label, cs.style
+			}
+			if r == m.row && c == m.col {
+				style = selectedStyle
+			}
+			line += " " + style.Render(cell(text, vexCellWidth))
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < rows+1 {
+		lines = append(lines, "")
+	}
+
+	info := m.cellInfoLines(width)
+	infoHeight := height - rows - 1
+	if len(info) > infoHeight {
+		info = info[:infoHeight]
+	}
+	lines = append(lines, info...)
+
+	return strings.Join(lines, "\n")
+}
+
+// cellInfoLines describes the status of the selected cell including the justification of not affected products
+func (m vexMatrix) cellInfoLines(width int) []string {
+	productID := m.products[m.row]
+	v := m.vulns[m.col]
+
+	status := m.status(productID, v)
+	if status == "" {
+		status = "no status given"
+	}
+
+	lines := []string{
+		faintStyle.Render(strings.Repeat("─", 40)),
+		fmt.Sprintf("%s %s", labelStyle.Render("Product:"), m.resolver.Label(productID)),
+		fmt.Sprintf("%s %s", labelStyle.Render("Vulnerability:"), vulnerabilityName(v)),
+		fmt.Sprintf("%s %s", labelStyle.Render("Status:"), status),
+	}
+
+	if status == vexKnownNotAffected {
+		flags, impacts := m.justification(productID, v)
+		if len(flags) == 0 {
+			flags = []string{"none given"}
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", labelStyle.Render("Justification:"), strings.Join(flags, ", ")))
+		for _, impact := range impacts {
+			lines = append(lines, labelStyle.Render("Impact statement:"))
+			lines = append(lines, strings.Split(indent(wrapText(impact, max(width-4, 20)), "  "), "\n")...)
+		}
+	}
+
+	return lines
+}
+
+// vulnerabilityName returns the CVE of a vulnerability, falling back to another ID or its title
+func vulnerabilityName(v csaf.Vulnerability) string {
+	switch {
+	case v.CVE != "":
+		return v.CVE
+	case len(v.IDs) > 0:
+		return v.IDs[0].Text
+	default:
+		return v.Title
+	}
+}
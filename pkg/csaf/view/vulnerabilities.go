@@ -0,0 +1,401 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mprpic/csafx/pkg/csaf"
+)
+
+// vulnerabilityList holds the navigation state of the vulnerabilities tab
+type vulnerabilityList struct {
+	cursor        int
+	offset        int
+	detail        bool
+	detailOffset  int
+	productCursor int
+	products      *csaf.ProductResolver
+}
+
+// update handles key presses for the vulnerabilities tab. If the user asks to
+// jump to a product in the product tree, its product ID is returned as well.
+func (l vulnerabilityList) update(msg tea.KeyMsg, vulns []csaf.Vulnerability, width, height int) (vulnerabilityList, string) {
+	count := len(vulns)
+
+	if l.detail {
+		products := productStatusIDs(vulns[l.cursor].ProductStatus)
+
+		switch msg.String() {
+		case "esc", "backspace", "left", "h":
+			l.detail = false
+			l.detailOffset = 0
+			l.productCursor = -1
+		case "n", "p":
+			if len(products) == 0 {
+				break
+			}
+			if msg.String() == "n" {
+				l.productCursor = (l.productCursor + 1) % len(products)
+			} else if l.productCursor <= 0 {
+				l.productCursor = len(products) - 1
+			} else {
+				l.productCursor--
+			}
+			// Scroll the selected product into view
+			_, line := vulnerabilityDetailLines(vulns[l.cursor], l.products, width, l.productCursor)
+			if line < l.detailOffset || line >= l.detailOffset+height {
+				l.detailOffset = max(line-height/2, 0)
+			}
+		case "enter":
+			if l.productCursor >= 0 && l.productCursor < len(products) {
+				return l, products[l.productCursor]
+			}
+		case "up", "k":
+			l.detailOffset = max(l.detailOffset-1, 0)
+		case "down", "j":
+			l.detailOffset++
+		case "pgup":
+			l.detailOffset = max(l.detailOffset-height, 0)
+		case "pgdown", " ":
+			l.detailOffset += height
+		case "home", "g":
+			l.detailOffset = 0
+		}
+
+		// Stop scrolling once the last detail line is visible
+		lines, _ := vulnerabilityDetailLines(vulns[l.cursor], l.products, width, l.productCursor)
+		l.detailOffset = min(l.detailOffset, max(len(lines)-height, 0))
+		return l, ""
+	}
+
+	if count == 0 {
+		return l, ""
+	}
+
+	// The first line of the list is taken up by the column header
+	rows := max(height-1, 1)
+
+	switch msg.String() {
+	case "up", "k":
+		l.cursor = max(l.cursor-1, 0)
+	case "down", "j":
+		l.cursor = min(l.cursor+1, count-1)
+	case "pgup":
+		l.cursor = max(l.cursor-rows, 0)
+	case "pgdown":
+		l.cursor = min(l.cursor+rows, count-1)
+	case "home", "g":
+		l.cursor = 0
+	case "end", "G":
+		l.cursor = count - 1
+	case "enter", "right", "l":
+		l.detail = true
+		l.detailOffset = 0
+		l.productCursor = -1
+	}
+
+	// Keep the cursor within the visible window
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	} else if l.cursor >= l.offset+rows {
+		l.offset = l.cursor - rows + 1
+	}
+
+	return l, ""
+}
+
+// help returns the key bindings relevant to the current state of the tab
+func (l vulnerabilityList) help() string {
+	if l.detail {
+		return "↑/↓ scroll • n/p select product • Enter show in product tree • Esc back to list"
+	}
+	return "↑/↓ move • Enter show details"
+}
+
+// view renders either the vulnerability list or the detail pane of the selected entry
+func (l vulnerabilityList) view(vulns []csaf.Vulnerability, width, height int) string {
+	if len(vulns) == 0 {
+		return faintStyle.Render("This document does not contain any vulnerabilities.")
+	}
+
+	if l.detail {
+		lines, _ := vulnerabilityDetailLines(vulns[l.cursor], l.products, width, l.productCursor)
+		offset := min(l.detailOffset, max(len(lines)-height, 0))
+		end := min(offset+height, len(lines))
+		return strings.Join(lines[offset:end], "\n")
+	}
+
+	const rowFormat = "%-16s %-10s %-5s %-9s %-10s %s"
+	titleWidth := max(width-56, 10)
+
+	lines := []string{labelStyle.Render(fmt.Sprintf(rowFormat, "CVE", "CWE", "CVSS", "Severity", "Released", "Title"))}
+
+	rows := max(height-1, 1)
+	end := min(l.offset+rows, len(vulns))
+	for i := l.offset; i < end; i++ {
+		v := vulns[i]
+
+		cve := v.CVE
+		if cve == "" && len(v.IDs) > 0 {
+			cve = v.IDs[0].Text
+		}
+
+		cwe := ""
+		if v.CWE != nil {
+			cwe = v.CWE.ID
+		}
+
+		score, severity := "", ""
+		if baseScore, baseSeverity, ok := highestBaseScore(v); ok {
+			score = fmt.Sprintf("%.1f", baseScore)
+			severity = baseSeverity
+		}
+
+		row := fmt.Sprintf(rowFormat,
+			truncate(cve, 16),
+			truncate(cwe, 10),
+			score,
+			truncate(severity, 9),
+			formatDate(v.ReleaseDate),
+			truncate(v.Title, titleWidth),
+		)
+
+		if i == l.cursor {
+			lines = append(lines, selectedStyle.Render(row))
+		} else {
+			lines = append(lines, row)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// vulnerabilityDetailLines renders all information about a single vulnerability
+// as a list of lines so that the detail pane can be scrolled. The product status
+// entry at index selectedProduct is highlighted and the index of its line returned.
+func vulnerabilityDetailLines(v csaf.Vulnerability, products *csaf.ProductResolver, width, selectedProduct int) ([]string, int) {
+	var b strings.Builder
+	wrapWidth := max(width-4, 20)
+
+	heading := v.CVE
+	if v.Title != "" {
+		if heading != "" {
+			heading += " — "
+		}
+		heading += v.Title
+	}
+	b.WriteString(titleStyle.Render(heading) + "\n")
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s %s\n", labelStyle.Render(label), value)
+		}
+	}
+	section := func(name string) {
+		fmt.Fprintf(&b, "\n%s\n", labelStyle.Render(name))
+	}
+
+	if v.CWE != nil {
+		field("CWE:", fmt.Sprintf("%s %s", v.CWE.ID, v.CWE.Name))
+	}
+	for _, id := range v.IDs {
+		field(id.SystemName+":", id.Text)
+	}
+	field("Discovered:", formatDate(v.DiscoveryDate))
+	field("Released:", formatDate(v.ReleaseDate))
+
+	if len(v.Scores) > 0 {
+		section("Scores")
+		for _, s := range v.Scores {
+			if s.CVSSv3 != nil {
+				fmt.Fprintf(&b, "  CVSS v%s %.1f %s %s\n", s.CVSSv3.Version, s.CVSSv3.BaseScore, s.CVSSv3.BaseSeverity, faintStyle.Render(s.CVSSv3.VectorString))
+			}
+			if s.CVSSv2 != nil {
+				fmt.Fprintf(&b, "  CVSS v%s %.1f %s\n", s.CVSSv2.Version, s.CVSSv2.BaseScore, faintStyle.Render(s.CVSSv2.VectorString))
+			}
+			writeProducts(&b, wrapWidth, products.Names(s.Products))
+		}
+	}
+
+	if len(v.Notes) > 0 {
+		section("Notes")
+		for _, n := range v.Notes {
+			title := n.Category
+			if n.Title != "" {
+				title = fmt.Sprintf("%s (%s)", n.Title, n.Category)
+			}
+			fmt.Fprintf(&b, "  %s\n", valueStyle.Render(title))
+			b.WriteString(indent(wrapText(n.Text, wrapWidth), "    ") + "\n")
+		}
+	}
+
+	if len(v.References) > 0 {
+		section("References")
+		for _, r := range v.References {
+			fmt.Fprintf(&b, "  - %s\n    %s\n", r.Summary, faintStyle.Render(r.URL))
+		}
+	}
+
+	if len(v.Remediations) > 0 {
+		section("Remediations")
+		for _, r := range v.Remediations {
+			fmt.Fprintf(&b, "  - [%s]\n", r.Category)
+			b.WriteString(indent(wrapText(r.Details, wrapWidth), "    ") + "\n")
+			if r.URL != "" {
+				fmt.Fprintf(&b, "    %s\n", faintStyle.Render(r.URL))
+			}
+			writeProducts(&b, wrapWidth, products.Names(products.Expand(r.ProductIDs, r.GroupIDs)))
+		}
+	}
+
+	if len(v.Threats) > 0 {
+		section("Threats")
+		for _, t := range v.Threats {
+			fmt.Fprintf(&b, "  - [%s]\n", t.Category)
+			b.WriteString(indent(wrapText(t.Details, wrapWidth), "    ") + "\n")
+			writeProducts(&b, wrapWidth, products.Names(products.Expand(t.ProductIDs, t.GroupIDs)))
+		}
+	}
+
+	selectedLine := 0
+	if v.ProductStatus != nil {
+		section("Product Status")
+		index := 0
+		for _, s := range productStatusEntries(v.ProductStatus) {
+			if len(s.ids) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "  %s (%d)\n", valueStyle.Render(s.name), len(s.ids))
+			for _, id := range s.ids {
+				line := "    - " + products.Label(id)
+				if index == selectedProduct {
+					selectedLine = strings.Count(b.String(), "\n")
+					line = selectedStyle.Render(line)
+				}
+				b.WriteString(line + "\n")
+				index++
+			}
+		}
+	}
+
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n"), selectedLine
+}
+
+// productStatusEntry pairs a product status category with the product IDs in it
+type productStatusEntry struct {
+	name string
+	ids  []string
+}
+
+// productStatusEntries lists the product status categories in the order used by the CSAF specification
+func productStatusEntries(ps *csaf.ProductStatus) []productStatusEntry {
+	return []productStatusEntry{
+		{"first_affected", ps.FirstAffected},
+		{"first_fixed", ps.FirstFixed},
+		{"fixed", ps.Fixed},
+		{"known_affected", ps.KnownAffected},
+		{"known_not_affected", ps.KnownNotAffected},
+		{"last_affected", ps.LastAffected},
+		{"recommended", ps.Recommended},
+		{"under_investigation", ps.UnderInvestigation},
+	}
+}
+
+// productStatusIDs returns the product IDs of all product status categories in display order
+func productStatusIDs(ps *csaf.ProductStatus) []string {
+	if ps == nil {
+		return nil
+	}
+	var ids []string
+	for _, s := range productStatusEntries(ps) {
+		ids = append(ids, s.ids...)
+	}
+	return ids
+}
+
+// writeProducts writes a wrapped list of the products a score, remediation or threat applies to
+func writeProducts(b *strings.Builder, width int, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	b.WriteString(faintStyle.Render(indent(wrapText("Products: "+strings.Join(names, "; "), width), "    ")) + "\n")
+}
+
+// highestBaseScore returns the highest CVSS base score of a vulnerability and its
+// severity, preferring CVSS v3 scores over CVSS v2 scores
+func highestBaseScore(v csaf.Vulnerability) (float64, string, bool) {
+	var score float64
+	var severity string
+	found := false
+
+	for _, s := range v.Scores {
+		if s.CVSSv3 != nil && (!found || s.CVSSv3.BaseScore > score) {
+			score, severity, found = s.CVSSv3.BaseScore, s.CVSSv3.BaseSeverity, true
+		}
+	}
+	if found {
+		return score, severity, true
+	}
+
+	for _, s := range v.Scores {
+		if s.CVSSv2 != nil && (!found || s.CVSSv2.BaseScore > score) {
+			score, found = s.CVSSv2.BaseScore, true
+		}
+	}
+	if found {
+		// CVSS v2 does not define a severity, use the NVD qualitative ratings
+		switch {
+		case score >= 7.0:
+			severity = "HIGH"
+		case score >= 4.0:
+			severity = "MEDIUM"
+		default:
+			severity = "LOW"
+		}
+	}
+
+	return score, severity, found
+}
+
+// formatDate shortens an RFC 3339 timestamp to a date, returning the input unchanged if it cannot be parsed
+func formatDate(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.Format(time.DateOnly)
+}
+
+// truncate shortens a string to the given number of characters, marking it with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// wrapText word-wraps plain text to the given width without padding the lines
+func wrapText(s string, width int) string {
+	lines := strings.Split(lipgloss.NewStyle().Width(width).Render(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indent prefixes every line of a multi-line string
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
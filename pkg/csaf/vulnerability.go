@@ -0,0 +1,151 @@
+package csaf
+
+// Vulnerability contains all information about a single vulnerability
+type Vulnerability struct {
+	Acknowledgments []Acknowledgment  `json:"acknowledgments,omitempty"`
+	CVE             string            `json:"cve,omitempty"`
+	CWE             *CWE              `json:"cwe,omitempty"`
+	DiscoveryDate   string            `json:"discovery_date,omitempty"`
+	Flags           []Flag            `json:"flags,omitempty"`
+	IDs             []VulnerabilityID `json:"ids,omitempty"`
+	Involvements    []Involvement     `json:"involvements,omitempty"`
+	Notes           []Note            `json:"notes,omitempty"`
+	ProductStatus   *ProductStatus    `json:"product_status,omitempty"`
+	References      []Reference       `json:"references,omitempty"`
+	ReleaseDate     string            `json:"release_date,omitempty"`
+	Remediations    []Remediation     `json:"remediations,omitempty"`
+	Scores          []Score           `json:"scores,omitempty"`
+	Threats         []Threat          `json:"threats,omitempty"`
+	Title           string            `json:"title,omitempty"`
+}
+
+// CWE identifies the weakness associated with a vulnerability
+type CWE struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Flag describes a machine-readable product status justification
+type Flag struct {
+	Date       string   `json:"date,omitempty"`
+	GroupIDs   []string `json:"group_ids,omitempty"`
+	Label      string   `json:"label"`
+	ProductIDs []string `json:"product_ids,omitempty"`
+}
+
+// VulnerabilityID is an identifier for a vulnerability in a tracking system other than CVE
+type VulnerabilityID struct {
+	SystemName string `json:"system_name"`
+	Text       string `json:"text"`
+}
+
+// Involvement describes a party's involvement in the vulnerability handling process
+type Involvement struct {
+	Date    string `json:"date,omitempty"`
+	Party   string `json:"party"`
+	Status  string `json:"status"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// ProductStatus lists the product IDs in each state with respect to a vulnerability
+type ProductStatus struct {
+	FirstAffected      []string `json:"first_affected,omitempty"`
+	FirstFixed         []string `json:"first_fixed,omitempty"`
+	Fixed              []string `json:"fixed,omitempty"`
+	KnownAffected      []string `json:"known_affected,omitempty"`
+	KnownNotAffected   []string `json:"known_not_affected,omitempty"`
+	LastAffected       []string `json:"last_affected,omitempty"`
+	Recommended        []string `json:"recommended,omitempty"`
+	UnderInvestigation []string `json:"under_investigation,omitempty"`
+}
+
+// Remediation describes how to address a vulnerability for a set of products
+type Remediation struct {
+	Category        string           `json:"category"`
+	Date            string           `json:"date,omitempty"`
+	Details         string           `json:"details"`
+	Entitlements    []string         `json:"entitlements,omitempty"`
+	GroupIDs        []string         `json:"group_ids,omitempty"`
+	ProductIDs      []string         `json:"product_ids,omitempty"`
+	RestartRequired *RestartRequired `json:"restart_required,omitempty"`
+	URL             string           `json:"url,omitempty"`
+}
+
+// RestartRequired describes what needs to be restarted after applying a remediation
+type RestartRequired struct {
+	Category string `json:"category"`
+	Details  string `json:"details,omitempty"`
+}
+
+// Score contains CVSS scores that apply to a set of products
+type Score struct {
+	CVSSv2   *CVSSv2  `json:"cvss_v2,omitempty"`
+	CVSSv3   *CVSSv3  `json:"cvss_v3,omitempty"`
+	Products []string `json:"products"`
+}
+
+// CVSSv2 is a CVSS version 2.0 score as defined by FIRST
+type CVSSv2 struct {
+	Version                    string   `json:"version"`
+	VectorString               string   `json:"vectorString"`
+	AccessVector               string   `json:"accessVector,omitempty"`
+	AccessComplexity           string   `json:"accessComplexity,omitempty"`
+	Authentication             string   `json:"authentication,omitempty"`
+	ConfidentialityImpact      string   `json:"confidentialityImpact,omitempty"`
+	IntegrityImpact            string   `json:"integrityImpact,omitempty"`
+	AvailabilityImpact         string   `json:"availabilityImpact,omitempty"`
+	BaseScore                  float64  `json:"baseScore"`
+	Exploitability             string   `json:"exploitability,omitempty"`
+	RemediationLevel           string   `json:"remediationLevel,omitempty"`
+	ReportConfidence           string   `json:"reportConfidence,omitempty"`
+	TemporalScore              *float64 `json:"temporalScore,omitempty"`
+	CollateralDamagePotential  string   `json:"collateralDamagePotential,omitempty"`
+	TargetDistribution         string   `json:"targetDistribution,omitempty"`
+	ConfidentialityRequirement string   `json:"confidentialityRequirement,omitempty"`
+	IntegrityRequirement       string   `json:"integrityRequirement,omitempty"`
+	AvailabilityRequirement    string   `json:"availabilityRequirement,omitempty"`
+	EnvironmentalScore         *float64 `json:"environmentalScore,omitempty"`
+}
+
+// CVSSv3 is a CVSS version 3.0 or 3.1 score as defined by FIRST
+type CVSSv3 struct {
+	Version                       string   `json:"version"`
+	VectorString                  string   `json:"vectorString"`
+	AttackVector                  string   `json:"attackVector,omitempty"`
+	AttackComplexity              string   `json:"attackComplexity,omitempty"`
+	PrivilegesRequired            string   `json:"privilegesRequired,omitempty"`
+	UserInteraction               string   `json:"userInteraction,omitempty"`
+	Scope                         string   `json:"scope,omitempty"`
+	ConfidentialityImpact         string   `json:"confidentialityImpact,omitempty"`
+	IntegrityImpact               string   `json:"integrityImpact,omitempty"`
+	AvailabilityImpact            string   `json:"availabilityImpact,omitempty"`
+	BaseScore                     float64  `json:"baseScore"`
+	BaseSeverity                  string   `json:"baseSeverity"`
+	ExploitCodeMaturity           string   `json:"exploitCodeMaturity,omitempty"`
+	RemediationLevel              string   `json:"remediationLevel,omitempty"`
+	ReportConfidence              string   `json:"reportConfidence,omitempty"`
+	TemporalScore                 *float64 `json:"temporalScore,omitempty"`
+	TemporalSeverity              string   `json:"temporalSeverity,omitempty"`
+	ConfidentialityRequirement    string   `json:"confidentialityRequirement,omitempty"`
+	IntegrityRequirement          string   `json:"integrityRequirement,omitempty"`
+	AvailabilityRequirement       string   `json:"availabilityRequirement,omitempty"`
+	ModifiedAttackVector          string   `json:"modifiedAttackVector,omitempty"`
+	ModifiedAttackComplexity      string   `json:"modifiedAttackComplexity,omitempty"`
+	ModifiedPrivilegesRequired    string   `json:"modifiedPrivilegesRequired,omitempty"`
+	ModifiedUserInteraction       string   `json:"modifiedUserInteraction,omitempty"`
+	ModifiedScope                 string   `json:"modifiedScope,omitempty"`
+	ModifiedConfidentialityImpact string   `json:"modifiedConfidentialityImpact,omitempty"`
+	ModifiedIntegrityImpact       string   `json:"modifiedIntegrityImpact,omitempty"`
+	ModifiedAvailabilityImpact    string   `json:"modifiedAvailabilityImpact,omitempty"`
+	EnvironmentalScore            *float64 `json:"environmentalScore,omitempty"`
+	EnvironmentalSeverity         string   `json:"environmentalSeverity,omitempty"`
+}
+
+// Threat describes the kind of threat a vulnerability poses to a set of products
+type Threat struct {
+	Category   string   `json:"category"`
+	Date       string   `json:"date,omitempty"`
+	Details    string   `json:"details"`
+	GroupIDs   []string `json:"group_ids,omitempty"`
+	ProductIDs []string `json:"product_ids,omitempty"`
+}